@@ -7,8 +7,11 @@ import (
 	"github.com/Masterminds/semver"
 	"github.com/magefile/mage/mg" // mg contains helpful utility functions, like Deps
 	"github.com/smecsia/go-utils/pkg/build"
-	"github.com/smecsia/go-utils/pkg/util"
+	"github.com/smecsia/go-utils/pkg/config"
+	"github.com/smecsia/go-utils/pkg/render"
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/sync/errgroup"
+	"io/ioutil"
 	"os"
 	"strings"
 	/**/)
@@ -23,14 +26,25 @@ type Generate mg.Namespace
 type Version mg.Namespace
 type Git mg.Namespace
 type Publish mg.Namespace
+type Config mg.Namespace
+type Tasks mg.Namespace
 
 var (
-	Ctx        = build.Init("./build.yaml", util.DefaultConsoleReader)
+	Ctx        = discoverConfig()
 	Generators = map[string]build.Generator{
 		"templates": nsGenerate.Templates,
 	}
 )
 
+// discoverConfig loads build.yaml of the project (BUILD_CONFIG env variable overrides its location)
+func discoverConfig() *build.Context {
+	ctx, _, err := build.DiscoverConfig("")
+	if err != nil {
+		panic(err)
+	}
+	return ctx
+}
+
 var (
 	nsGenerate = Generate{}
 	nsBuild    = Build{}
@@ -65,11 +79,11 @@ func Test() error {
 // Unit Run unit tests
 func (Tests) Unit() error {
 	if !Ctx.IsSkipTests() {
-		verbose := ""
+		args := []string{"go", "test"}
 		if Ctx.IsVerbose() {
-			verbose = " -v"
+			args = append(args, "-v")
 		}
-		return Ctx.RunCmd(build.Cmd{Command: "go test" + verbose + " ./...", Env: Ctx.CurrentPlatform().GoEnv()})
+		return Ctx.RunCmd(build.Cmd{Args: append(args, "./..."), Env: Ctx.CurrentPlatform().GoEnv()})
 	}
 	return nil
 }
@@ -89,30 +103,36 @@ func (b Build) All() error {
 func (Build) Build() error {
 	mg.Deps(nsGenerate.All)
 	mg.Deps(nsTests.Unit)
-	return Ctx.ForAllTargets(func(target build.Target) error {
-		return Ctx.ForAllPlatforms(func(platform build.Platform) error {
-			return Ctx.Build(target, platform)
-		})
-	})
+	return buildTargets()
 }
 
-// DeploymentsAll Builds deployments plugin for all platforms at once
-func (Build) DeploymentsCmdAll() error {
-	return Ctx.ForAllPlatforms(func(platform build.Platform) error {
-		return Ctx.Build(Ctx.Target("deployments"), platform)
-	})
+// Plan Prints artifacts and bundles which would be built without building them (REPORT_FORMAT: yaml or json)
+func (Build) Plan() error {
+	plan, err := Ctx.Plan()
+	if err != nil {
+		return err
+	}
+	format := os.Getenv("REPORT_FORMAT")
+	if format == "" {
+		format = render.FormatYAML
+	}
+	return render.Write(os.Stdout, format, plan)
 }
 
-// Deployments Builds deployments plugin for current platform only
-func (Build) DeploymentsCmd() error {
-	mg.Deps(nsGenerate.Templates)
-	return Ctx.Build(Ctx.Target("deployments"), Ctx.CurrentPlatform())
+// Report Prints report of artifacts built by preceding targets (REPORT_FORMAT: yaml or json)
+func (Build) Report() error {
+	format := os.Getenv("REPORT_FORMAT")
+	if format == "" {
+		format = render.FormatYAML
+	}
+	return Ctx.WriteReport(os.Stdout, format)
 }
 
 // BuildAll Builds build plugin for all platforms at once
 func (Build) BuildCmdAll() error {
-	return Ctx.ForAllPlatforms(func(platform build.Platform) error {
-		return Ctx.Build(Ctx.Target("build"), platform)
+	target := Ctx.Target("build")
+	return Ctx.ForTargetPlatforms(target, func(platform build.Platform) error {
+		return Ctx.Build(target, platform)
 	})
 }
 
@@ -123,7 +143,7 @@ func (Build) InstallDeps() error {
 	if _, err := os.Stat(cmd); os.IsNotExist(err) {
 		cmd = "dep"
 	}
-	return Ctx.RunCmd(build.Cmd{Command: fmt.Sprintf("%s ensure -v", cmd)})
+	return Ctx.RunCmd(build.Cmd{Args: []string{cmd, "ensure", "-v"}})
 }
 
 // Clean Cleans up output directory
@@ -132,34 +152,138 @@ func (Build) Clean() error {
 	return os.RemoveAll(Ctx.OutDir)
 }
 
-// Bundles Build tarballs out of binaries
+// CleanCache Removes build cache
+func (Build) CleanCache() error {
+	fmt.Println("Cleaning build cache...")
+	return os.RemoveAll(Ctx.CachePath())
+}
+
+// Bundles Build archives out of binaries
 func (b Build) Bundles() error {
-	fmt.Println("Bundling tarballs...")
-	var eg errgroup.Group
-	for _, target := range Ctx.ActiveTargets() {
-		for _, platform := range Ctx.Platforms {
-			t := target
-			p := platform
-			bundle := Ctx.Bundle(t, p)
-			buildTarball := func() error {
-				if err := Ctx.BuildBundle(bundle); err != nil {
-					return err
-				}
-				return nil
-			}
-			if Ctx.IsParallel() {
-				eg.Go(buildTarball)
-			} else if err := buildTarball(); err != nil {
-				return err
-			}
-		}
+	return buildBundles()
+}
+
+// Checksums Writes SHA256SUMS of archives signed with RELEASE_SIGNING_KEY (if set)
+func (b Build) Checksums() error {
+	mg.Deps(b.Bundles)
+	return writeChecksums()
+}
+
+// PublicKey Prints public key of RELEASE_SIGNING_KEY in minisign format
+func (Build) PublicKey() error {
+	privateKey, err := config.ReadPrivateKey(Ctx.SigningKey)
+	if err != nil {
+		return err
 	}
-	return eg.Wait()
+	return build.WriteMinisignPublicKey(os.Stdout, privateKey.Public().(ed25519.PublicKey))
+}
+
+func buildTargets() error {
+	err := Ctx.ForAllTargets(func(target build.Target) error {
+		return Ctx.ForTargetPlatforms(target, func(platform build.Platform) error {
+			return Ctx.Build(target, platform)
+		})
+	})
+	return saveReport(err)
+}
+
+func buildBundles() error {
+	fmt.Println("Bundling archives...")
+	err := Ctx.ForAllTargets(func(target build.Target) error {
+		return Ctx.ForTargetPlatforms(target, func(platform build.Platform) error {
+			return Ctx.BuildBundle(Ctx.Bundle(target, platform))
+		})
+	})
+	return saveReport(err)
+}
+
+func writeChecksums() error {
+	files, err := Ctx.WriteChecksums(Ctx.Bundles())
+	for _, file := range files {
+		fmt.Println(fmt.Sprintf("Written %s", file))
+	}
+	return err
+}
+
+// saveReport writes build report to BUILD_REPORT (if set) keeping error of the build
+func saveReport(err error) error {
+	if reportErr := Ctx.SaveReport(); reportErr != nil {
+		fmt.Println(fmt.Sprintf("WARN: failed to save build report: %s", reportErr))
+	}
+	return err
 }
 
 // --------------------------------------
 // Publish
 
+// All Publishes bundles and checksum manifests to the repository configured in publish section
+func (Publish) All() error {
+	mg.Deps(nsBuild.Checksums)
+	return nsPublish.Artifacts()
+}
+
+// Artifacts Publishes already built bundles and checksum manifests skipping ones published before
+func (Publish) Artifacts() error {
+	results, err := Ctx.PublishBundles()
+	for _, result := range results {
+		fmt.Println(fmt.Sprintf("%s %s", result.Status, result.Name))
+	}
+	return err
+}
+
+// Plan Prints artifacts which would be published
+func (Publish) Plan() error {
+	artifacts, err := Ctx.PublishArtifacts()
+	if err != nil {
+		return err
+	}
+	for _, artifact := range artifacts {
+		fmt.Println(fmt.Sprintf("%s -> %s", artifact.File, artifact.Name))
+	}
+	return nil
+}
+
+// --------------------------------------
+// Task graph targets
+
+// the release pipeline as the task graph (tasks declared in build.yaml are added to it or extend its tasks)
+func init() {
+	for _, task := range []build.Task{
+		{TaskConfig: build.TaskConfig{Name: "generate", Description: "Invoke all generators"}, Run: nsGenerate.All},
+		{TaskConfig: build.TaskConfig{Name: "test", Description: "Run unit tests", Deps: []string{"generate"}}, Run: nsTests.Unit},
+		{TaskConfig: build.TaskConfig{Name: "build", Description: "Build all targets", Deps: []string{"test"}}, Run: buildTargets},
+		{TaskConfig: build.TaskConfig{Name: "bundles", Description: "Build archives", Deps: []string{"build"}}, Run: buildBundles},
+		{TaskConfig: build.TaskConfig{Name: "checksums", Description: "Write checksum manifests", Deps: []string{"bundles"}}, Run: writeChecksums},
+		{TaskConfig: build.TaskConfig{Name: "publish", Description: "Publish bundles", Deps: []string{"checksums"}}, Run: nsPublish.Artifacts},
+	} {
+		Ctx.DefineTask(task)
+	}
+}
+
+// Run Runs TASK (comma-separated list, all tasks by default) along with its dependencies
+func (Tasks) Run() error {
+	return Ctx.RunTasks(taskNames()...)
+}
+
+// Graph Prints graph of TASK (all tasks by default) in GRAPH_FORMAT (dot by default or json)
+func (Tasks) Graph() error {
+	graph, err := Ctx.TaskGraph()
+	if err != nil {
+		return err
+	}
+	format := os.Getenv("GRAPH_FORMAT")
+	if format == "" {
+		format = build.GraphFormatDOT
+	}
+	return graph.Write(os.Stdout, format, taskNames()...)
+}
+
+func taskNames() []string {
+	if tasks := os.Getenv("TASK"); tasks != "" {
+		return strings.Split(tasks, ",")
+	}
+	return nil
+}
 
 // --------------------------------------
 // Generators
@@ -185,12 +309,69 @@ func (Generate) Templates() error {
 	if paths, err := Ctx.ListAllSubDirs(templatesDir); err != nil {
 		return err
 	} else {
-		return Ctx.RunCmd(build.Cmd{Command: fmt.Sprintf(
-			"go run %s -nometadata -pkg render -prefix %s/ -o %s %s",
-			Ctx.VendorPath("github.com/go-bindata/go-bindata/go-bindata"),
-			templatesDir, Ctx.Path("pkg/render/templates.tpl.go"), strings.Join(paths, " ")),
-			Env: Ctx.CurrentPlatform().GoEnv(), Wd: templatesDir})
+		args := []string{"go", "run", Ctx.VendorPath("github.com/go-bindata/go-bindata/go-bindata"),
+			"-nometadata", "-pkg", "render", "-prefix", templatesDir + "/", "-o", Ctx.Path("pkg/render/templates.tpl.go")}
+		return Ctx.RunCmd(build.Cmd{Args: append(args, paths...), Env: Ctx.CurrentPlatform().GoEnv(), Wd: templatesDir})
+	}
+}
+
+// SampleConfig Prints fully commented sample build.yaml
+func (Generate) SampleConfig() error {
+	return build.WriteSampleConfig(os.Stdout)
+}
+
+// --------------------------------------
+// Config targets
+
+// Diff Prints difference between config files (DIFF_FROM and DIFF_TO), config file and its effective state
+// (DIFF_TO is not set) or config file under two env profiles (DIFF_FROM_ENV and DIFF_TO_ENV).
+// Output format is set by DIFF_FORMAT (text, json or yaml)
+func (Config) Diff() error {
+	from := os.Getenv("DIFF_FROM")
+	if from == "" {
+		from = Ctx.GetConfigFilePath()
+	}
+	diff, err := build.DiffConfig(from, os.Getenv("DIFF_TO"), os.Getenv("DIFF_FROM_ENV"), os.Getenv("DIFF_TO_ENV"))
+	if err != nil {
+		return err
+	}
+	format := os.Getenv("DIFF_FORMAT")
+	if format == "" {
+		format = config.DiffFormatText
+	}
+	return config.WriteDiff(os.Stdout, format, diff)
+}
+
+// Keygen Generates ed25519 key pair for signing config (CONFIG_SIGNING_KEY, public key is written to <key>.pub)
+func (Config) Keygen() error {
+	keyFile := signingKeyFile()
+	return config.GenerateSigningKeys(keyFile, keyFile+".pub")
+}
+
+// Sign Signs build.yaml with private key (CONFIG_SIGNING_KEY) writing signature to build.yaml.sig
+func (Config) Sign() error {
+	privateKey, err := config.ReadPrivateKey(signingKeyFile())
+	if err != nil {
+		return err
+	}
+	return config.SignFile(Ctx.GetConfigFilePath(), privateKey)
+}
+
+// Checksum Prints checksum of build.yaml to pin it with BUILD_CONFIG_SHA256
+func (Config) Checksum() error {
+	data, err := ioutil.ReadFile(Ctx.GetConfigFilePath())
+	if err != nil {
+		return err
 	}
+	fmt.Println(config.Checksum(data))
+	return nil
+}
+
+func signingKeyFile() string {
+	if keyFile := os.Getenv("CONFIG_SIGNING_KEY"); keyFile != "" {
+		return keyFile
+	}
+	return "build.key"
 }
 
 // --------------------------------------
@@ -199,22 +380,115 @@ func (Generate) Templates() error {
 // BumpMajor Bump major version
 func (Version) BumpMajor() error {
 	v := Ctx.SemVer().IncMajor()
-	return Ctx.SetVersionInConfig(v.String())
+	return Ctx.SaveVersion(v.String())
 }
 
 // BumpMinor Bump minor version
 func (Version) BumpMinor() error {
 	v := Ctx.SemVer().IncMinor()
-	return Ctx.SetVersionInConfig(v.String())
+	return Ctx.SaveVersion(v.String())
 }
 
 // BumpPatch Bump patch version
 func (Version) BumpPatch() error {
 	v := Ctx.SemVer().IncPatch()
-	return Ctx.SetVersionInConfig(v.String())
+	return Ctx.SaveVersion(v.String())
+}
+
+// Bump Bumps version according to conventional commits made since the last version tag
+func (Version) Bump() error {
+	bump, err := Ctx.BumpVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Println(fmt.Sprintf("%s -> %s (%s, %d conventional commits)", bump.From, bump.Version, bump.Level, len(bump.Commits)))
+	return nil
+}
+
+// Next Prints version inferred from conventional commits made since the last version tag
+func (Version) Next() error {
+	bump, err := Ctx.NextVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Println(bump.Version)
+	return nil
+}
+
+// Changelog Prints changelog of changes made since the last version tag (or between FROM and TO)
+func (Version) Changelog() error {
+	bump, err := Ctx.NextVersion()
+	if err != nil {
+		return err
+	}
+	changelog, err := Ctx.GenerateChangelog(os.Getenv("FROM"), os.Getenv("TO"), bump.Version)
+	if err != nil {
+		return err
+	}
+	return Ctx.WriteChangelog(os.Stdout, changelog)
+}
+
+// Release Bumps version, prepends changelog to CHANGELOG.md and commits both
+func (Version) Release() error {
+	bump, err := Ctx.Release()
+	if err != nil {
+		return err
+	}
+	fmt.Println(fmt.Sprintf("Released %s (previous version %s)", bump.Version, bump.From))
+	return nil
+}
+
+// Prerelease Sets next prerelease version of the CHANNEL (rc by default): 1.2.0-rc.1 -> 1.2.0-rc.2
+func (Version) Prerelease() error {
+	version, err := Ctx.PrereleaseVersion(os.Getenv("CHANNEL"))
+	if err != nil {
+		return err
+	}
+	fmt.Println(fmt.Sprintf("%s -> %s", Ctx.Version, version))
+	return Ctx.SaveVersion(version)
+}
+
+// Final Sets final version of the prerelease: 1.2.0-rc.2 -> 1.2.0
+func (Version) Final() error {
+	version, err := Ctx.FinalVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Println(fmt.Sprintf("%s -> %s", Ctx.Version, version))
+	return Ctx.SaveVersion(version)
+}
+
+// Snapshot Prints snapshot version of the CHANNEL (dev by default) derived from commits since the last version tag
+func (Version) Snapshot() error {
+	version, err := Ctx.SnapshotVersion(os.Getenv("CHANNEL"))
+	if err != nil {
+		return err
+	}
+	fmt.Println(version)
+	return nil
+}
+
+// Describe Prints description of HEAD relative to the nearest version tag (git describe)
+func (Version) Describe() error {
+	description, err := Ctx.DescribeVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Println(description)
+	return nil
+}
+
+// Tag Creates tag of the current version and pushes it to the remote
+func (Version) Tag() error {
+	tag, err := Ctx.TagVersion(Ctx.Version, fmt.Sprintf("Version %s", Ctx.Version))
+	if err != nil {
+		return err
+	}
+	fmt.Println(tag)
+	return nil
 }
 
-// Print Prints full version (current + git hash)
+// Print Prints full version (formatted according to VERSION_FORMAT)
 func (Version) Print() error {
 	fmt.Println(Ctx.FullVersion())
 	return nil
@@ -225,6 +499,6 @@ func (Version) Set() error {
 	if v, err := semver.NewVersion(os.Getenv("VERSION")); err != nil {
 		return err
 	} else {
-		return Ctx.SetVersionInConfig(v.String())
+		return Ctx.SaveVersion(v.String())
 	}
 }
@@ -0,0 +1,271 @@
+package build
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	ArchiveTarGz = "tar.gz"
+	ArchiveTarXz = "tar.xz"
+	ArchiveZip   = "zip"
+
+	// DefaultArchiveName is the template of archive name used unless archive.name is set
+	DefaultArchiveName = "{{.Target}}-{{.OS}}-{{.Arch}}"
+)
+
+var (
+	ArchiveFormats = []string{ArchiveTarGz, ArchiveTarXz, ArchiveZip}
+
+	// archiveEpoch is the modification time of archived files unless SOURCE_DATE_EPOCH is set
+	// (zip can not keep earlier dates)
+	archiveEpoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// ArchiveConfig defines archives (bundles) built out of target binaries
+type ArchiveConfig struct {
+	Format string   `yaml:"format,omitempty" description:"Archive format (zip for windows and tar.gz for other platforms by default)" allowed:"tar.gz,tar.xz,zip"`
+	Name   string   `yaml:"name,omitempty" description:"Template of archive name without extension (fields: Target, OS, Arch, Version)" example:"{{.Target}}-{{.Version}}-{{.OS}}-{{.Arch}}"`
+	Files  []string `yaml:"files,omitempty" description:"Extra files or directories relative to project root added to archive (globs are allowed)" example:"LICENSE"`
+}
+
+// ArchiveName describes values available in the template of archive name
+type ArchiveName struct {
+	Target  string
+	OS      string
+	Arch    string
+	Version string
+}
+
+// archiveEntry is the file added to archive
+type archiveEntry struct {
+	name string
+	path string
+	mode os.FileMode
+}
+
+// Validate checks that archive format and name template are valid
+func (a *ArchiveConfig) Validate() error {
+	if a.Format != "" && !isArchiveFormat(a.Format) {
+		return fmt.Errorf("unsupported archive format %q, expected one of %v", a.Format, ArchiveFormats)
+	}
+	if a.Name != "" {
+		// render sample name so that unknown fields are reported at load time
+		sample := ArchiveName{Target: "app", OS: "linux", Arch: "amd64", Version: "1.0.0"}
+		if _, err := renderArchiveName(a.Name, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArchiveFormat returns archive format of the target's bundle for platform
+func (ctx *Context) ArchiveFormat(target Target, platform Platform) string {
+	if target.Archive.Format != "" {
+		return target.Archive.Format
+	} else if ctx.Archive.Format != "" {
+		return ctx.Archive.Format
+	} else if platform.isWindows() {
+		return ArchiveZip
+	}
+	return ArchiveTarGz
+}
+
+// ArchiveName returns file name of the target's bundle for platform
+func (ctx *Context) ArchiveName(target Target, platform Platform) (string, error) {
+	nameTemplate := DefaultArchiveName
+	if target.Archive.Name != "" {
+		nameTemplate = target.Archive.Name
+	} else if ctx.Archive.Name != "" {
+		nameTemplate = ctx.Archive.Name
+	}
+	data := ArchiveName{Target: target.Name, OS: platform.GOOS, Arch: platform.GOARCH, Version: ctx.Version}
+	name, err := renderArchiveName(nameTemplate, data)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to render archive name of %s", target.Name)
+	}
+	return name + "." + ctx.ArchiveFormat(target, platform), nil
+}
+
+// renderArchiveName renders template of archive name
+func renderArchiveName(nameTemplate string, data ArchiveName) (string, error) {
+	tpl, err := template.New("archive").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid archive name template %q", nameTemplate)
+	}
+	var name bytes.Buffer
+	if err := tpl.Execute(&name, data); err != nil {
+		return "", errors.Wrapf(err, "invalid archive name template %q", nameTemplate)
+	}
+	return name.String(), nil
+}
+
+// ArchiveFiles returns extra files of the target's archive (global ones followed by the target's ones)
+func (ctx *Context) ArchiveFiles(target Target) []string {
+	return append(append([]string{}, ctx.Archive.Files...), target.Archive.Files...)
+}
+
+// archiveTime returns modification time of archived files
+func (ctx *Context) archiveTime() (time.Time, error) {
+	if ctx.SourceDateEpoch == "" {
+		return archiveEpoch, nil
+	}
+	return ctx.BuildDate()
+}
+
+// bundleEntries returns binary of the bundle along with extra files sorted by name
+func (ctx *Context) bundleEntries(bundle Bundle) ([]archiveEntry, error) {
+	binary := ctx.OutFile(bundle.Target, bundle.Platform)
+	entries := []archiveEntry{{name: filepath.Base(binary), path: binary, mode: 0755}}
+	seen := map[string]bool{entries[0].name: true}
+	root := ctx.ProjectRoot()
+	for _, pattern := range ctx.ArchiveFiles(bundle.Target) {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid archive file pattern %q", pattern)
+		} else if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match archive file pattern %q", pattern)
+		}
+		for _, match := range matches {
+			if err := filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				relPath, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				name := filepath.ToSlash(relPath)
+				if seen[name] {
+					return nil
+				}
+				seen[name] = true
+				mode := os.FileMode(0644)
+				if info.Mode()&0111 != 0 {
+					mode = 0755
+				}
+				entries = append(entries, archiveEntry{name: name, path: path, mode: mode})
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	return entries, nil
+}
+
+// writeArchiveFile writes archive atomically so that interrupted build does not leave partial archive
+func writeArchiveFile(path string, format string, entries []archiveEntry, mtime time.Time) error {
+	tmpFile := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+	if err := writeArchive(f, format, entries, mtime); err != nil {
+		f.Close()
+		os.Remove(tmpFile)
+		return errors.Wrapf(err, "failed to write archive %s", path)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return os.Rename(tmpFile, path)
+}
+
+// writeArchive writes entries in the given order with fixed modification time, owner and permissions
+// so that archive of the same files is always the same
+func writeArchive(w io.Writer, format string, entries []archiveEntry, mtime time.Time) error {
+	switch format {
+	case ArchiveTarGz:
+		gz := gzip.NewWriter(w)
+		if err := writeTar(gz, entries, mtime); err != nil {
+			return err
+		}
+		return gz.Close()
+	case ArchiveTarXz:
+		xzw, err := xz.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		if err := writeTar(xzw, entries, mtime); err != nil {
+			return err
+		}
+		return xzw.Close()
+	case ArchiveZip:
+		return writeZip(w, entries, mtime)
+	}
+	return fmt.Errorf("unsupported archive format %q", format)
+}
+
+func writeTar(w io.Writer, entries []archiveEntry, mtime time.Time) error {
+	tw := tar.NewWriter(w)
+	for _, entry := range entries {
+		f, err := os.Open(entry.path)
+		if err != nil {
+			return err
+		}
+		info, err := f.Stat()
+		if err == nil {
+			err = tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     entry.name,
+				Mode:     int64(entry.mode),
+				Size:     info.Size(),
+				ModTime:  mtime,
+			})
+		}
+		if err == nil {
+			_, err = io.Copy(tw, f)
+		}
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func writeZip(w io.Writer, entries []archiveEntry, mtime time.Time) error {
+	zw := zip.NewWriter(w)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: mtime}
+		header.SetMode(entry.mode)
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(entry.path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fw, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func isArchiveFormat(format string) bool {
+	for _, f := range ArchiveFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package build_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/ulikunitz/xz"
+)
+
+func TestBuildBundle(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "bundle")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	writeFile := func(name string, content string, mode os.FileMode) {
+		Expect(os.MkdirAll(path.Dir(path.Join(tmpDir, name)), os.ModePerm)).To(BeNil())
+		Expect(ioutil.WriteFile(path.Join(tmpDir, name), []byte(content), mode)).To(BeNil())
+	}
+	writeFile("build.yaml", "outDir: bin\n", 0644)
+	writeFile("LICENSE", "MIT", 0600)
+	writeFile("completions/app.bash", "complete app", 0644)
+	writeFile("completions/app.zsh", "compdef app", 0755)
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(tmpDir)).To(BeNil())
+
+	ctx := &Context{OutDir: "bin", Version: "1.0.0", Parallel: "false",
+		Archive: ArchiveConfig{Name: "{{.Target}}-{{.Version}}-{{.OS}}-{{.Arch}}", Files: []string{"LICENSE"}}}
+	target := Target{Name: "app", Path: "cmd/app/main.go", Archive: ArchiveConfig{Files: []string{"completions"}}}
+	linux, windows := Platform{GOOS: "linux", GOARCH: "amd64"}, Platform{GOOS: "windows", GOARCH: "amd64"}
+	writeFile("bin/linux-amd64/app", "binary", 0644)
+	writeFile("bin/windows-amd64/app.exe", "binary", 0644)
+
+	bundle := ctx.Bundle(target, linux)
+	Expect(bundle.Format).To(Equal(ArchiveTarGz))
+	Expect(path.Base(bundle.BinaryFile)).To(Equal("app-1.0.0-linux-amd64.tar.gz"))
+	Expect(ctx.BuildBundle(bundle)).To(BeNil())
+	first, err := ioutil.ReadFile(bundle.BinaryFile)
+	Expect(err).To(BeNil())
+
+	later := time.Now().Add(time.Hour)
+	Expect(os.Chtimes(path.Join(tmpDir, "LICENSE"), later, later)).To(BeNil())
+	Expect(ctx.BuildBundle(bundle)).To(BeNil())
+	Expect(ioutil.ReadFile(bundle.BinaryFile)).To(Equal(first), "archive must be reproducible")
+
+	gz, err := gzip.NewReader(open(bundle.BinaryFile))
+	Expect(err).To(BeNil())
+	Expect(readTar(gz)).To(Equal([]string{
+		"LICENSE -rw-r--r-- 1980-01-01T00:00:00Z",
+		"app -rwxr-xr-x 1980-01-01T00:00:00Z",
+		"completions/app.bash -rw-r--r-- 1980-01-01T00:00:00Z",
+		"completions/app.zsh -rwxr-xr-x 1980-01-01T00:00:00Z",
+	}))
+
+	ctx.SourceDateEpoch = "1500000000"
+	target.Archive.Format = ArchiveTarXz
+	bundle = ctx.Bundle(target, linux)
+	Expect(path.Base(bundle.BinaryFile)).To(Equal("app-1.0.0-linux-amd64.tar.xz"))
+	Expect(ctx.BuildBundle(bundle)).To(BeNil())
+	xzr, err := xz.NewReader(open(bundle.BinaryFile))
+	Expect(err).To(BeNil())
+	Expect(readTar(xzr)).To(ContainElement("app -rwxr-xr-x 2017-07-14T02:40:00Z"))
+
+	target.Archive.Format = ""
+	bundle = ctx.Bundle(target, windows)
+	Expect(bundle.Format).To(Equal(ArchiveZip))
+	Expect(ctx.BuildBundle(bundle)).To(BeNil())
+	zr, err := zip.OpenReader(bundle.BinaryFile)
+	Expect(err).To(BeNil())
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name+" "+f.Mode().String())
+	}
+	Expect(names).To(Equal([]string{"LICENSE -rw-r--r--", "app.exe -rwxr-xr-x",
+		"completions/app.bash -rw-r--r--", "completions/app.zsh -rwxr-xr-x"}))
+	Expect(ioutil.ReadFile(bundle.ChecksumFile)).NotTo(BeEmpty())
+
+	target.Archive.Files = []string{"NOTICE"}
+	Expect(ctx.BuildBundle(ctx.Bundle(target, linux))).NotTo(BeNil())
+	Expect((&ArchiveConfig{Format: "rar"}).Validate()).NotTo(BeNil())
+	Expect((&ArchiveConfig{Name: "{{.Target"}).Validate()).NotTo(BeNil())
+	Expect((&ArchiveConfig{Name: "{{.Foo}}"}).Validate()).To(MatchError(ContainSubstring("invalid archive name template")))
+	Expect((&ArchiveConfig{Name: "{{.Target}}-{{.Version}}"}).Validate()).To(BeNil())
+}
+
+func open(file string) io.Reader {
+	data, err := ioutil.ReadFile(file)
+	Expect(err).To(BeNil())
+	return bytes.NewReader(data)
+}
+
+func readTar(r io.Reader) []string {
+	var res []string
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return res
+		}
+		Expect(err).To(BeNil())
+		Expect(header.Uid).To(Equal(0))
+		res = append(res, header.Name+" "+header.FileInfo().Mode().String()+" "+header.ModTime.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,42 @@
+package build_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+)
+
+func TestSetVersionInConfigAudit(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "audit")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	configDir := path.Join(tmpDir, "etc", "app")
+	Expect(os.MkdirAll(configDir, os.ModePerm)).To(BeNil())
+	configPath := path.Join(configDir, "build.yaml")
+	Expect(ioutil.WriteFile(configPath, []byte("outDir: bin\nversion: 0.1.0\n"), 0644)).To(BeNil())
+	// config is not in the project root (e.g. found via BUILD_CONFIG)
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(tmpDir)).To(BeNil())
+
+	ctx := &Context{OutDir: "bin", Version: "0.1.0"}
+	ctx.SetConfigFilePath(configPath)
+	Expect(ctx.SetVersionInConfig("0.2.0")).To(BeNil())
+
+	data, err := ioutil.ReadFile(path.Join(configDir, "build.yaml.audit.jsonl"))
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(ContainSubstring(`"new":"0.2.0"`))
+
+	ctx.AuditLog = "-"
+	Expect(ctx.SetVersionInConfig("0.3.0")).To(BeNil())
+	data, err = ioutil.ReadFile(path.Join(configDir, "build.yaml.audit.jsonl"))
+	Expect(err).To(BeNil())
+	Expect(string(data)).NotTo(ContainSubstring(`"new":"0.3.0"`))
+}
@@ -1,17 +1,17 @@
 package build
 
 import (
-	"crypto/sha256"
 	"fmt"
 	"github.com/pkg/errors"
+	"github.com/smecsia/go-utils/pkg/config"
 	"go/build"
-	"golang.org/x/sync/errgroup"
 	"io"
+	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
 var (
@@ -28,78 +28,108 @@ func (ctx Context) CurrentPlatform() Platform {
 	}
 }
 
-// BuildAllPlatforms runs build for all platforms for target
+// BuildAllPlatforms runs build for all platforms of target
 func (ctx *Context) BuildAllPlatforms(target Target) error {
-	var eg errgroup.Group
-	for _, platform := range ctx.Platforms {
-		p := platform
-		if ctx.IsParallel() {
-			eg.Go(func() error {
-				return ctx.Build(target, p)
-			})
-		} else if err := ctx.Build(target, p); err != nil {
-			return err
-		}
-	}
-	return eg.Wait()
+	platforms := ctx.TargetPlatforms(target)
+	return ctx.forAll(len(platforms), func(i int) error {
+		return ctx.Build(target, platforms[i])
+	})
 }
 
-// ForAllPlatforms runs build for all platforms for target
+// ForAllPlatforms runs action for all active platforms (at most MaxJobs at a time)
 func (ctx *Context) ForAllPlatforms(action func(platform Platform) error) error {
-	var eg errgroup.Group
-	for _, platform := range ctx.ActivePlatforms() {
-		p := platform
-		buildFnc := func() error {
-			return action(p)
-		}
-		if ctx.IsParallel() {
-			eg.Go(buildFnc)
-		} else if err := buildFnc(); err != nil {
-			return err
-		}
-	}
-	return eg.Wait()
+	platforms := ctx.ActivePlatforms()
+	return ctx.forAll(len(platforms), func(i int) error {
+		return action(platforms[i])
+	})
 }
 
-// ForAllTargets execute some action for each target
+// ForTargetPlatforms runs action for all active platforms of target (at most MaxJobs at a time)
+func (ctx *Context) ForTargetPlatforms(target Target, action func(platform Platform) error) error {
+	platforms := ctx.ActiveTargetPlatforms(target)
+	return ctx.forAll(len(platforms), func(i int) error {
+		return action(platforms[i])
+	})
+}
+
+// ForAllTargets execute some action for each target (at most MaxJobs at a time)
 func (ctx *Context) ForAllTargets(action func(target Target) error) error {
-	var eg errgroup.Group
-	for _, target := range ctx.ActiveTargets() {
-		t := target
-		actionFor := func() error {
-			return action(t)
+	targets := ctx.ActiveTargets()
+	return ctx.forAll(len(targets), func(i int) error {
+		return action(targets[i])
+	})
+}
+
+// Build runs go build for a certain target and platform recording the artifact in the report
+func (ctx *Context) Build(target Target, platform Platform) error {
+	artifact := ctx.newArtifactReport(target, platform)
+	start := time.Now()
+	err := ctx.RunTask(TaskName(target, platform), func(out *TaskOutput) error {
+		out.Logger.Logf("Building %s for %s...", target, platform)
+
+		outFile := ctx.OutFile(target, platform)
+
+		err := os.MkdirAll(filepath.Dir(outFile), os.ModePerm)
+		if err != nil {
+			return err
 		}
-		if ctx.IsParallel() {
-			eg.Go(actionFor)
-		} else if err := actionFor(); err != nil {
+
+		cmd, settings, keySettings, err := ctx.goBuild(target, platform)
+		if err != nil {
 			return err
 		}
-	}
-	if err := eg.Wait(); err != nil {
-		return err
-	}
-	return nil
-}
+		artifact.Command, artifact.LdFlags = cmd.String(), settings.LdFlags
+		cacheKey := ""
+		if !ctx.IsNoCache() {
+			if cacheKey, err = ctx.BuildCacheKey(target, platform, keySettings); err != nil {
+				out.Logger.Logf("WARN: failed to compute build cache key: %s", err)
+			} else if restored, err := ctx.restoreFromCache(cacheKey, outFile); err != nil {
+				out.Logger.Logf("WARN: failed to restore %s from build cache: %s", outFile, err)
+			} else if restored {
+				out.Logger.Logf("Restored %s from build cache (%s)", outFile, cacheKey[:12])
+				artifact.Cached = true
+				return nil
+			}
+		}
 
-// Build runs go build for a certain target and platform
-func (ctx *Context) Build(target Target, platform Platform) error {
-	fmt.Println(fmt.Sprintf("Building %s for %s...", target, platform))
+		cmd.Context, cmd.Stdout, cmd.Stderr = out.Context(), out.Stdout(), out.Stderr()
+		if err := ctx.RunCmd(cmd); err != nil {
+			return err
+		}
 
-	outFile := ctx.OutFile(target, platform)
+		if cacheKey != "" {
+			if err := ctx.storeInCache(cacheKey, outFile); err != nil {
+				out.Logger.Logf("WARN: failed to store %s in build cache: %s", outFile, err)
+			}
+		}
+		return nil
+	})
+	ctx.recordArtifact(artifact, time.Since(start), err)
+	return err
+}
 
-	err := os.MkdirAll(filepath.Dir(outFile), os.ModePerm)
+// goBuild returns go build command of target for platform along with its settings and settings identifying
+// its result in the build cache (build date alone must not invalidate cached artifacts while the rest of
+// build metadata must, since it is linked into the binary)
+func (ctx *Context) goBuild(target Target, platform Platform) (Cmd, BuildSettings, BuildSettings, error) {
+	info, err := ctx.BuildInfo()
 	if err != nil {
-		return err
+		return Cmd{}, BuildSettings{}, BuildSettings{}, err
 	}
-
-	return ctx.RunCmd(Cmd{Env: platform.GoEnv(),
-		Command: "go build -ldflags \"-X main.Version=" + ctx.FullVersion() + "\" -o \"" + outFile + "\" \"" + ctx.SourceFile(target) + "\""})
+	settings := ctx.TargetSettings(target)
+	keySettings := settings
+	settings.LdFlags = strings.TrimSpace(keySettings.LdFlags + " " + ctx.BuildInfoLdFlags(info))
+	info.Date = ""
+	keySettings.LdFlags = strings.TrimSpace(keySettings.LdFlags + " " + ctx.BuildInfoLdFlags(info))
+	args := append(append([]string{"go", "build"}, settings.GoFlags()...),
+		"-o", ctx.OutFile(target, platform), ctx.SourceFile(target))
+	return Cmd{Env: settings.GoEnv(platform), Args: args}, settings, keySettings, nil
 }
 
-// RunCmd runs any command in the sub shell
+// RunCmd runs command (argv form or shell form in the sub shell) returning *ExitError if it fails
 func (ctx *Context) RunCmd(cmd Cmd) error {
-	run := exec.Command("bash", "-c", cmd.Command)
-	runString := fmt.Sprintf("%s \"%s\"", run.Path, strings.Join(run.Args[1:], "\" \""))
+	run, runCtx, cancel := cmd.command()
+	defer cancel()
 	if cmd.Wd == "" {
 		cwd, err := os.Getwd()
 		if err != nil {
@@ -109,18 +139,24 @@ func (ctx *Context) RunCmd(cmd Cmd) error {
 	} else {
 		run.Dir = cmd.Wd
 	}
-	run.Stdout = os.Stdout
-	run.Stderr = os.Stderr
-	run.Env = os.Environ()
-	for _, env := range cmd.Env {
-		run.Env = append(run.Env, env)
+	stdout, stderr := cmd.Stdout, cmd.Stderr
+	if stdout == nil {
+		stdout = os.Stdout
 	}
-	fmt.Println(fmt.Sprintf("Executing '%s'", runString))
-	err := run.Run()
-	if err != nil {
-		return errors.Wrapf(err, "Failed to execute '%s'", runString)
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	stderrTail := &tailWriter{size: stderrTailSize}
+	run.Stdout = stdout
+	run.Stderr = io.MultiWriter(stderr, stderrTail)
+	// non-nil env keeps os/exec from passing environment of the current process
+	run.Env = []string{}
+	if !cmd.ReplaceEnv {
+		run.Env = os.Environ()
 	}
-	return nil
+	run.Env = append(run.Env, cmd.Env...)
+	fmt.Fprintln(stdout, fmt.Sprintf("Executing '%s'", cmd))
+	return exitError(runCtx, cmd, run.Run(), stderrTail)
 }
 
 // GenerateSwaggerClient generates swagger client from URL into relative path
@@ -134,44 +170,31 @@ func (ctx *Context) GenerateSwaggerClient(relPath string, swaggerURL string, app
 	if err := ctx.RunCmd(Cmd{Command: fmt.Sprintf("curl -s %s/api/swagger.json | jq -S . > %s", swaggerURL, swaggerFile)}); err != nil {
 		return err
 	}
-	return ctx.RunCmd(Cmd{Command: fmt.Sprintf(
-		"go run %s generate client -f %s --skip-validation -t %s -A %s",
-		ctx.VendorPath("github.com/go-swagger/go-swagger/cmd/swagger"), swaggerFile, basePath, appName),
+	return ctx.RunCmd(Cmd{Args: []string{"go", "run", ctx.VendorPath("github.com/go-swagger/go-swagger/cmd/swagger"),
+		"generate", "client", "-f", swaggerFile, "--skip-validation", "-t", basePath, "-A", appName},
 		Env: ctx.CurrentPlatform().GoEnv()})
 }
 
-// ProjectRoot finds git root traversing from the current directory up to the dir with .git dir
+// ProjectRoot finds project root traversing from the current directory up to the dir with build.yaml
 func (ctx *Context) ProjectRoot() string {
-	cwd, err := os.Getwd()
-	if err != nil {
-		panic(err)
-	}
-	_, err = os.Stat(filepath.Join(cwd, "build.yaml"))
-	for os.IsNotExist(err) && filepath.Dir(cwd) != "/" {
-		cwd = filepath.Dir(cwd)
-		_, err = os.Stat(filepath.Join(cwd, "build.yaml"))
-	}
-	if filepath.Dir(cwd) == "/" {
-		panic("Could not determine project root! Make sure to run build from the root!")
-	}
-	return cwd
+	return findRootWith(ConfigFileName, "Could not determine project root! Make sure to run build from the root!")
 }
 
 // GitRoot finds git root traversing from the current directory up to the dir with .git dir
 func (ctx *Context) GitRoot() string {
+	return findRootWith(".git", "Could not determine Git root for the project")
+}
+
+func findRootWith(fileName string, message string) string {
 	cwd, err := os.Getwd()
 	if err != nil {
 		panic(err)
 	}
-	_, err = os.Stat(filepath.Join(cwd, ".git"))
-	for os.IsNotExist(err) && filepath.Dir(cwd) != "/" {
-		cwd = filepath.Dir(cwd)
-		_, err = os.Stat(filepath.Join(cwd, ".git"))
-	}
-	if filepath.Dir(cwd) == "/" {
-		panic("Could not determine Git root for the project")
+	path, err := config.FindUpward(cwd, fileName)
+	if err != nil {
+		panic(errors.Wrap(err, message))
 	}
-	return cwd
+	return filepath.Dir(path)
 }
 
 // Target returns target by its name defined in config
@@ -184,30 +207,47 @@ func (ctx *Context) Target(name string) Target {
 	panic("Failed to find target with name: " + name)
 }
 
-// Tarball builds tarball for target and platform
-func (ctx *Context) BuildBundle(tarball Bundle) error {
-	if err := ctx.RunCmd(Cmd{Command: "tar -C " + ctx.OutFileDir(tarball.Target, tarball.Platform) +
-		" -czf " + tarball.BinaryFile + " " + ctx.OutFileName(tarball.Target, tarball.Platform)}); err != nil {
-		return err
-	}
+// BuildBundle builds archive of the target's binary and extra files along with its checksum recording it in the report
+func (ctx *Context) BuildBundle(bundle Bundle) error {
+	err := ctx.RunTask(TaskName(bundle.Target, bundle.Platform), func(out *TaskOutput) error {
+		out.Logger.Logf("Bundling %s...", bundle.BinaryFile)
+		entries, err := ctx.bundleEntries(bundle)
+		if err != nil {
+			return err
+		}
+		mtime, err := ctx.archiveTime()
+		if err != nil {
+			return err
+		}
+		if err := writeArchiveFile(bundle.BinaryFile, bundle.Format, entries, mtime); err != nil {
+			return err
+		}
 
-	if err := writeFileChecksum(tarball.BinaryFile, tarball.ChecksumFile); err != nil {
-		return err
-	}
+		if err := writeFileChecksum(bundle.BinaryFile, bundle.ChecksumFile); err != nil {
+			return err
+		}
 
-	return nil
+		return nil
+	})
+	ctx.recordBundle(bundle, err)
+	return err
 }
 
-// Tarball returns resulting tarball file names for target and platform
+// Bundle returns resulting archive file names for target and platform
 func (ctx *Context) Bundle(target Target, platform Platform) Bundle {
-	tarName := ctx.BundleFile(target, platform)
-	checksumName := fmt.Sprintf("%s.sha256", tarName)
-	return Bundle{Target: target, Platform: platform, BinaryFile: tarName, ChecksumFile: checksumName}
+	archiveName := ctx.BundleFile(target, platform)
+	checksumName := fmt.Sprintf("%s.sha256", archiveName)
+	return Bundle{Target: target, Platform: platform, Format: ctx.ArchiveFormat(target, platform),
+		BinaryFile: archiveName, ChecksumFile: checksumName}
 }
 
-// BundleFile built file path for target
+// BundleFile built archive path for target (panics if archive name template fails)
 func (ctx *Context) BundleFile(target Target, platform Platform) string {
-	return filepath.Join(ctx.OutFileDir(target, platform), fmt.Sprintf("%s-%s-%s.tar.gz", target.Name, platform.GOOS, platform.GOARCH))
+	name, err := ctx.ArchiveName(target, platform)
+	if err != nil {
+		panic(err)
+	}
+	return filepath.Join(ctx.OutFileDir(target, platform), name)
 }
 
 // OutFile built file path for target
@@ -219,14 +259,18 @@ func (ctx *Context) OutFileDir(target Target, platform Platform) string {
 	return filepath.Join(ctx.OutPath(), fmt.Sprintf("%s-%s", platform.GOOS, platform.GOARCH))
 }
 
-// OutFileName file name based for target based on platform
+// OutFileName file name based for target based on platform (output of target or its name)
 func (ctx *Context) OutFileName(target Target, platform Platform) string {
 	var osExt string
 	if platform.isWindows() {
 		osExt = ".exe"
 	}
+	name := target.Name
+	if target.Output != "" {
+		name = target.Output
+	}
 
-	return fmt.Sprintf("%s%s", target.Name, osExt)
+	return fmt.Sprintf("%s%s", name, osExt)
 }
 
 // OutPath returns full path to output directory
@@ -256,7 +300,7 @@ func (ctx *Context) VendorPath(relPath string) string {
 
 // GoEnv returns environment variables necessary for Go to run properly
 func (platform Platform) GoEnv() []string {
-	res := make([]string, len(GoBuildEnv)+2)
+	res := make([]string, 0, len(GoBuildEnv)+2)
 	for _, env := range GoBuildEnv {
 		res = append(res, env)
 	}
@@ -300,29 +344,11 @@ func (p *Platform) String() string {
 	return fmt.Sprintf("%s-%s", p.GOOS, p.GOARCH)
 }
 
+// writeFileChecksum writes sha256 checksum of the file in format of sha256sum
 func writeFileChecksum(inputFile string, outputFile string) error {
-	f, err := os.Open(inputFile)
-
+	sum, err := fileChecksum(ChecksumSHA256, inputFile)
 	if err != nil {
 		return err
 	}
-
-	defer f.Close()
-
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, f); err != nil {
-		return err
-	}
-
-	fHash, err := os.Create(outputFile)
-
-	if err != nil {
-		return err
-	}
-
-	defer fHash.Close()
-
-	_, err = fHash.Write([]byte(fmt.Sprintf("%x", hasher.Sum(nil))))
-
-	return err
+	return ioutil.WriteFile(outputFile, []byte(checksumLine(sum, filepath.Base(inputFile))), 0644)
 }
@@ -0,0 +1,114 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smecsia/go-utils/pkg/buildinfo"
+)
+
+const (
+	// DefaultBuildInfoPackage is the import path of the package build info is injected into
+	DefaultBuildInfoPackage = "github.com/smecsia/go-utils/pkg/buildinfo"
+)
+
+var (
+	buildInfoMutex sync.Mutex
+)
+
+// BuildInfo returns metadata injected into built binaries, it is detected once per context
+func (ctx *Context) BuildInfo() (buildinfo.Info, error) {
+	buildInfoMutex.Lock()
+	defer buildInfoMutex.Unlock()
+	if ctx.buildInfo != nil {
+		return *ctx.buildInfo, nil
+	}
+	gitClient := ctx.gitClient()
+	info := buildinfo.Info{Version: ctx.Version, Builder: ctx.builder()}
+	var err error
+	if info.Commit, err = gitClient.Hash(); err != nil {
+		return info, errors.Wrap(err, "unable to detect Git hash")
+	}
+	if info.ShortCommit, err = gitClient.HashShort(); err != nil {
+		return info, errors.Wrap(err, "unable to detect Git hash")
+	}
+	if info.Branch, err = gitClient.CurrentBranch(); err != nil {
+		return info, errors.Wrap(err, "unable to detect Git branch")
+	}
+	clean, _, err := gitClient.IsWorkTreeClean()
+	if err != nil {
+		return info, errors.Wrap(err, "unable to check Git work tree")
+	}
+	info.Dirty = !clean
+	date, err := ctx.BuildDate()
+	if err != nil {
+		return info, err
+	}
+	info.Date = date.Format(time.RFC3339)
+	ctx.buildInfo = &info
+	return info, nil
+}
+
+// BuildDate returns date of the build: SOURCE_DATE_EPOCH if it is set (reproducible builds) or current time
+func (ctx *Context) BuildDate() (time.Time, error) {
+	if ctx.SourceDateEpoch == "" {
+		return time.Now().UTC(), nil
+	}
+	epoch, err := strconv.ParseInt(ctx.SourceDateEpoch, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid SOURCE_DATE_EPOCH %q", ctx.SourceDateEpoch)
+	}
+	return time.Unix(epoch, 0).UTC(), nil
+}
+
+// BuildInfoLdFlags returns linker flags setting main.Version and variables of the build info package
+func (ctx *Context) BuildInfoLdFlags(info buildinfo.Info) string {
+	flags := []string{ldFlagVar("main.Version", ctx.FullVersion())}
+	if ctx.BuildInfoPackage == "" || ctx.BuildInfoPackage == "-" {
+		return strings.Join(flags, " ")
+	}
+	vars := []struct{ name, value string }{
+		{"Version", info.Version},
+		{"Commit", info.Commit},
+		{"ShortCommit", info.ShortCommit},
+		{"Branch", info.Branch},
+		{"Dirty", strconv.FormatBool(info.Dirty)},
+		{"Date", info.Date},
+		{"Builder", info.Builder},
+	}
+	for _, v := range vars {
+		if v.value != "" {
+			flags = append(flags, ldFlagVar(ctx.BuildInfoPackage+"."+v.name, v.value))
+		}
+	}
+	return strings.Join(flags, " ")
+}
+
+// builder returns BUILDER or user@host
+func (ctx *Context) builder() string {
+	if ctx.Builder != "" {
+		return ctx.Builder
+	}
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		return fmt.Sprintf("%s@%s", name, host)
+	}
+	return name
+}
+
+// ldFlagVar returns -X flag quoting value if it contains spaces (single quotes are dropped)
+func ldFlagVar(name string, value string) string {
+	if strings.ContainsAny(value, " \t'\"") {
+		return fmt.Sprintf("-X '%s=%s'", name, strings.Replace(value, "'", "", -1))
+	}
+	return fmt.Sprintf("-X %s=%s", name, value)
+}
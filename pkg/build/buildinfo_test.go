@@ -0,0 +1,59 @@
+package build_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/smecsia/go-utils/pkg/git/mock"
+)
+
+func TestBuildInjectsBuildInfo(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "buildinfo")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	files := map[string]string{
+		"build.yaml":      "outDir: bin\n",
+		"go.mod":          "module example.com/app\n\ngo 1.12\n",
+		"cmd/app/main.go": "package main\n\nvar Version, Commit, Branch, Dirty, Date, Builder string\n\nfunc main() { println(Version, Commit, Branch, Dirty, Date, Builder) }\n",
+	}
+	for name, content := range files {
+		Expect(os.MkdirAll(path.Dir(path.Join(tmpDir, name)), os.ModePerm)).To(BeNil())
+		Expect(ioutil.WriteFile(path.Join(tmpDir, name), []byte(content), 0644)).To(BeNil())
+	}
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(tmpDir)).To(BeNil())
+
+	gitMock := &mock.GitMock{}
+	gitMock.On("HashShort").Return("abc123d", nil)
+	gitMock.On("Hash").Return("abc123def456", nil)
+	gitMock.On("CurrentBranch").Return("feature", nil)
+	gitMock.On("IsWorkTreeClean").Return(false, "M main.go", nil)
+	ctx := &Context{OutDir: "bin", Version: "1.2.3", Parallel: "false", NoCache: "true",
+		BuildInfoPackage: "main", Builder: "ci agent", SourceDateEpoch: "1500000000"}
+	ctx.SetGit(gitMock)
+
+	info, err := ctx.BuildInfo()
+	Expect(err).To(BeNil())
+	Expect(info.Date).To(Equal("2017-07-14T02:40:00Z"))
+	Expect(info.Dirty).To(BeTrue())
+
+	target := Target{Name: "app", Path: "cmd/app/main.go"}
+	platform := Platform{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}
+	Expect(ctx.Build(target, platform)).To(BeNil())
+	out, err := exec.Command(ctx.OutFile(target, platform)).CombinedOutput()
+	Expect(err).To(BeNil())
+	Expect(string(out)).To(Equal("1.2.3 abc123def456 feature true 2017-07-14T02:40:00Z ci agent\n"))
+
+	_, err = (&Context{SourceDateEpoch: "yesterday"}).BuildDate()
+	Expect(err).NotTo(BeNil())
+}
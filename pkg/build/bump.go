@@ -0,0 +1,182 @@
+package build
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"github.com/smecsia/go-utils/pkg/git"
+)
+
+const (
+	BumpNone  = "none"
+	BumpPatch = "patch"
+	BumpMinor = "minor"
+	BumpMajor = "major"
+)
+
+var (
+	conventionalHeader = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?: (.+)$`)
+	breakingFooter     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+	bumpLevels         = map[string]int{BumpNone: 0, BumpPatch: 1, BumpMinor: 2, BumpMajor: 3}
+)
+
+// ConventionalCommit is the commit which message follows Conventional Commits (type(scope)!: description)
+type ConventionalCommit struct {
+	Hash        string
+	Type        string
+	Scope       string
+	Description string
+	Body        string
+	Breaking    bool
+}
+
+// VersionBump describes next version inferred from commits made since the last version tag
+type VersionBump struct {
+	// Tag is the last version tag (empty if there is none and version of config is used)
+	Tag     string
+	From    string
+	Version string
+	Level   string
+	Commits []ConventionalCommit
+}
+
+// versionTag is the tag which name is a semantic version
+type versionTag struct {
+	name    string
+	version *semver.Version
+}
+
+// ParseConventionalCommit parses commit message returning false if it does not follow Conventional Commits
+func ParseConventionalCommit(commit git.Commit) (ConventionalCommit, bool) {
+	parts := strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)
+	match := conventionalHeader.FindStringSubmatch(strings.TrimSpace(parts[0]))
+	if match == nil {
+		return ConventionalCommit{}, false
+	}
+	res := ConventionalCommit{Hash: commit.Hash, Type: strings.ToLower(match[1]), Scope: match[2],
+		Description: match[4], Breaking: match[3] == "!"}
+	if len(parts) > 1 {
+		res.Body = strings.TrimSpace(parts[1])
+		res.Breaking = res.Breaking || breakingFooter.MatchString(res.Body)
+	}
+	return res, true
+}
+
+// Level returns version part the commit bumps: breaking changes bump major, features bump minor,
+// fixes and performance improvements bump patch
+func (c ConventionalCommit) Level() string {
+	switch {
+	case c.Breaking:
+		return BumpMajor
+	case c.Type == "feat":
+		return BumpMinor
+	case c.Type == "fix" || c.Type == "perf":
+		return BumpPatch
+	}
+	return BumpNone
+}
+
+// NextVersion infers next version from conventional commits made since the last version tag reachable
+// from HEAD (breaking changes bump minor while major version is 0)
+func (ctx *Context) NextVersion() (VersionBump, error) {
+	res := VersionBump{From: ctx.Version, Level: BumpNone}
+	commits, tag, err := ctx.commitsSince("", "")
+	if err != nil {
+		return res, err
+	}
+	from := tag.version
+	if from != nil {
+		res.Tag = tag.name
+	} else if from, err = semver.NewVersion(ctx.Version); err != nil {
+		return res, errors.Wrapf(err, "invalid version %q", ctx.Version)
+	}
+	res.From = from.String()
+	for _, commit := range commits {
+		if parsed, ok := ParseConventionalCommit(commit); ok {
+			res.Commits = append(res.Commits, parsed)
+			if level := parsed.Level(); bumpLevels[level] > bumpLevels[res.Level] {
+				res.Level = level
+			}
+		}
+	}
+	next := *from
+	switch res.Level {
+	case BumpMajor:
+		if from.Major() == 0 {
+			next = from.IncMinor()
+		} else {
+			next = from.IncMajor()
+		}
+	case BumpMinor:
+		next = from.IncMinor()
+	case BumpPatch:
+		next = from.IncPatch()
+	}
+	res.Version = next.String()
+	return res, nil
+}
+
+// BumpVersion saves version inferred from conventional commits (if it has changed) writing it to
+// the config file or tagging HEAD if version is taken from git
+func (ctx *Context) BumpVersion() (VersionBump, error) {
+	bump, err := ctx.NextVersion()
+	if err != nil || bump.Version == ctx.Version {
+		return bump, err
+	}
+	return bump, ctx.SaveVersion(bump.Version)
+}
+
+// commitsSince returns commits reachable from revision to (HEAD if empty) which are not reachable from
+// revision from or from the last version tag if from is empty (the tag is returned as well)
+func (ctx *Context) commitsSince(from string, to string) ([]git.Commit, versionTag, error) {
+	gitClient := ctx.gitClient()
+	if from != "" {
+		fromHash, err := gitClient.ResolveRevision(from)
+		if err != nil {
+			return nil, versionTag{}, err
+		}
+		commits, err := gitClient.Log(to, func(commit git.Commit) bool {
+			return commit.Hash == fromHash
+		})
+		return commits, versionTag{}, err
+	}
+	tags, err := ctx.versionTags()
+	if err != nil {
+		return nil, versionTag{}, err
+	}
+	// the highest version wins if several tagged commits are reached (e.g. via merged branches)
+	var last versionTag
+	commits, err := gitClient.Log(to, func(commit git.Commit) bool {
+		tag, ok := tags[commit.Hash]
+		if ok && (last.version == nil || tag.version.GreaterThan(last.version)) {
+			last = tag
+		}
+		return ok
+	})
+	return commits, last, err
+}
+
+// versionTags returns version tags (ones with TagPrefix) by hashes of commits they point to
+// (the highest version if there are several)
+func (ctx *Context) versionTags() (map[string]versionTag, error) {
+	tags, err := ctx.gitClient().Tags()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read git tags")
+	}
+	res := make(map[string]versionTag)
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag.Name, ctx.TagPrefix) {
+			continue
+		}
+		version, err := semver.NewVersion(strings.TrimPrefix(tag.Name, ctx.TagPrefix))
+		if err != nil {
+			continue
+		}
+		if current, ok := res[tag.Hash]; !ok || version.GreaterThan(current.version) {
+			res[tag.Hash] = versionTag{name: tag.Name, version: version}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,155 @@
+package build_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/smecsia/go-utils/pkg/git"
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+type testRepo struct {
+	dir  string
+	repo *gogit.Repository
+	when time.Time
+}
+
+func newTestRepo(dir string) *testRepo {
+	repo, err := gogit.PlainInit(dir, false)
+	Expect(err).To(BeNil())
+	return &testRepo{dir: dir, repo: repo, when: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (r *testRepo) commit(message string, parents ...plumbing.Hash) plumbing.Hash {
+	wt, err := r.repo.Worktree()
+	Expect(err).To(BeNil())
+	Expect(ioutil.WriteFile(path.Join(r.dir, "CHANGES"), []byte(message), 0644)).To(BeNil())
+	_, err = wt.Add("CHANGES")
+	Expect(err).To(BeNil())
+	r.when = r.when.Add(time.Minute)
+	hash, err := wt.Commit(message, &gogit.CommitOptions{Parents: parents,
+		Author: &object.Signature{Name: "dev", Email: "dev@example.com", When: r.when}})
+	Expect(err).To(BeNil())
+	return hash
+}
+
+func (r *testRepo) tag(name string, hash plumbing.Hash, annotated bool) {
+	var opts *gogit.CreateTagOptions
+	if annotated {
+		opts = &gogit.CreateTagOptions{Message: name, Tagger: &object.Signature{Name: "dev", Email: "dev@example.com", When: r.when}}
+	}
+	_, err := r.repo.CreateTag(name, hash, opts)
+	Expect(err).To(BeNil())
+}
+
+func TestNextVersionFromConventionalCommits(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "bump")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	Expect(ioutil.WriteFile(path.Join(tmpDir, "build.yaml"), []byte("version: 0.1.0\n"), 0644)).To(BeNil())
+	repo := newTestRepo(tmpDir)
+	ctx := &Context{Version: "0.1.0", AuditLog: "-"}
+	ctx.SetConfigFilePath(path.Join(tmpDir, "build.yaml"))
+	ctx.SetGit(git.New(tmpDir))
+
+	initial := repo.commit("feat!: initial version")
+	bump, err := ctx.NextVersion()
+	Expect(err).To(BeNil())
+	Expect(bump.Tag).To(BeEmpty())
+	Expect(bump.Level).To(Equal(BumpMajor))
+	Expect(bump.Version).To(Equal("0.2.0"))
+
+	repo.tag("v1.0.0", initial, true)
+	repo.tag("not-a-version", initial, false)
+	bump, err = ctx.NextVersion()
+	Expect(err).To(BeNil())
+	Expect(bump.Tag).To(Equal("v1.0.0"))
+	Expect(bump.Level).To(Equal(BumpNone))
+	Expect(bump.Version).To(Equal("1.0.0"))
+
+	repo.commit("fix: handle empty config")
+	repo.commit("docs: describe bump")
+	repo.commit("update readme")
+	bump, err = ctx.NextVersion()
+	Expect(err).To(BeNil())
+	Expect(bump.Level).To(Equal(BumpPatch))
+	Expect(bump.Version).To(Equal("1.0.1"))
+	Expect(bump.Commits).To(HaveLen(2))
+	Expect(bump.Commits[0].Type).To(Equal("docs"))
+
+	repo.tag("v1.0.1", repo.commit("feat(api): add endpoint"), false)
+	repo.commit("refactor: simplify\n\nBREAKING CHANGE: config format has changed")
+	bump, err = ctx.BumpVersion()
+	Expect(err).To(BeNil())
+	Expect(bump.Tag).To(Equal("v1.0.1"))
+	Expect(bump.From).To(Equal("1.0.1"))
+	Expect(bump.Version).To(Equal("2.0.0"))
+	Expect(ctx.Version).To(Equal("2.0.0"))
+	cfg, err := ioutil.ReadFile(path.Join(tmpDir, "build.yaml"))
+	Expect(err).To(BeNil())
+	Expect(string(cfg)).To(ContainSubstring("version: 2.0.0"))
+}
+
+func TestNextVersionAfterMerge(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "bump")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	repo := newTestRepo(tmpDir)
+	ctx := &Context{Version: "0.1.0", AuditLog: "-"}
+	ctx.SetGit(git.New(tmpDir))
+
+	initial := repo.commit("chore: initial version")
+	// feature branch is started before the release and merged after it
+	feature := repo.commit("feat: add bundles")
+	release := repo.commit("fix: prepare release", initial)
+	repo.tag("v1.0.0", release, true)
+	repo.commit("chore: merge feature", release, feature)
+
+	bump, err := ctx.NextVersion()
+	Expect(err).To(BeNil())
+	Expect(bump.Tag).To(Equal("v1.0.0"))
+	Expect(bump.Commits).To(HaveLen(2))
+	Expect(bump.Commits[0].Description).To(Equal("merge feature"))
+	Expect(bump.Commits[1].Description).To(Equal("add bundles"))
+	Expect(bump.Version).To(Equal("1.1.0"))
+
+	// commits reachable from the tag are excluded regardless of their dates
+	late := repo.commit("fix: commit with clock ahead")
+	repo.when = repo.when.Add(-time.Hour)
+	repo.tag("v1.1.0", repo.commit("chore: release", late), true)
+	repo.when = repo.when.Add(2 * time.Hour)
+	repo.commit("docs: describe release")
+	bump, err = ctx.NextVersion()
+	Expect(err).To(BeNil())
+	Expect(bump.Tag).To(Equal("v1.1.0"))
+	Expect(bump.Commits).To(HaveLen(1))
+	Expect(bump.Commits[0].Description).To(Equal("describe release"))
+}
+
+func TestParseConventionalCommit(t *testing.T) {
+	RegisterTestingT(t)
+
+	commit, ok := ParseConventionalCommit(git.Commit{Hash: "abc", Message: "feat(build)!: per-target settings\n\nDetails"})
+	Expect(ok).To(BeTrue())
+	Expect(commit).To(Equal(ConventionalCommit{Hash: "abc", Type: "feat", Scope: "build",
+		Description: "per-target settings", Body: "Details", Breaking: true}))
+	Expect(commit.Level()).To(Equal(BumpMajor))
+
+	commit, ok = ParseConventionalCommit(git.Commit{Message: "perf: faster cache\n\nBREAKING-CHANGE: new cache dir"})
+	Expect(ok).To(BeTrue())
+	Expect(commit.Breaking).To(BeTrue())
+
+	_, ok = ParseConventionalCommit(git.Commit{Message: "Merge branch 'master'"})
+	Expect(ok).To(BeFalse())
+}
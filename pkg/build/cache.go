@@ -0,0 +1,235 @@
+package build
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	cacheAppDir = "go-utils/build"
+)
+
+var (
+	// cacheSourceExts are extensions of files which affect compilation result
+	cacheSourceExts = []string{".go", ".c", ".h", ".s", ".S", ".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx", ".m", ".f", ".F", ".syso", ".swig", ".swigcxx"}
+	// cacheLockFiles are dependency lock files in the project root
+	cacheLockFiles = []string{"go.mod", "go.sum", "Gopkg.lock"}
+	// cacheVolatileGoEnv are variables of go env which differ between runs (temp dir of gcc flags)
+	cacheVolatileGoEnv = map[string]bool{"GOGCCFLAGS": true}
+
+	goVersionOnce   sync.Once
+	goVersion       string
+	goVersionErr    error
+	goListDepsFlags = []string{"list", "-deps", "-f", "{{if not .Standard}}{{.Dir}}{{end}}"}
+)
+
+// CachePath returns path to the build cache directory
+func (ctx *Context) CachePath() string {
+	if ctx.CacheDir != "" {
+		return ctx.CacheDir
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return filepath.Join(cacheDir, cacheAppDir)
+}
+
+// BuildCacheKey returns key of the build cache computed from sources of the target's package and its
+// dependencies, lock files, build settings, effective Go environment of the build and Go version.
+// Settings include build metadata linked into the binary, so with default version format (which adds
+// git hash to the version) and build info package cached artifacts are reused within the same commit only.
+// Commits which don't touch sources (e.g. docs only) reuse cached artifacts when commit is not linked into
+// the binary: plain or semver version format without hash and build info disabled (buildInfoPackage: -)
+func (ctx *Context) BuildCacheKey(target Target, platform Platform, settings BuildSettings) (string, error) {
+	hash := sha256.New()
+	version, err := currentGoVersion()
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(hash, "go: %s\n", version)
+	for _, flag := range settings.GoFlags() {
+		fmt.Fprintf(hash, "flag: %s\n", flag)
+	}
+	env, err := ctx.goEnv(settings.GoEnv(platform))
+	if err != nil {
+		return "", err
+	}
+	for _, value := range env {
+		fmt.Fprintf(hash, "env: %s\n", value)
+	}
+	for _, lockFile := range cacheLockFiles {
+		if err := hashFile(hash, ctx.Path(lockFile)); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	dirs, err := ctx.packageDirs(target, platform, settings)
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range dirs {
+		if err := hashSources(hash, ctx.ProjectRoot(), dir); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// CachedArtifact returns path to the cached artifact for the key
+func (ctx *Context) CachedArtifact(key string) string {
+	return filepath.Join(ctx.CachePath(), key[:2], key)
+}
+
+// restoreFromCache copies cached artifact to the output file returning false if there is no artifact for the key
+func (ctx *Context) restoreFromCache(key string, outFile string) (bool, error) {
+	cached := ctx.CachedArtifact(key)
+	if _, err := os.Stat(cached); os.IsNotExist(err) {
+		return false, nil
+	}
+	return true, copyFile(cached, outFile, 0755)
+}
+
+// storeInCache copies built artifact to the cache
+func (ctx *Context) storeInCache(key string, outFile string) error {
+	cached := ctx.CachedArtifact(key)
+	if err := os.MkdirAll(filepath.Dir(cached), os.ModePerm); err != nil {
+		return err
+	}
+	tmpFile := fmt.Sprintf("%s.%d.tmp", cached, os.Getpid())
+	if err := copyFile(outFile, tmpFile, 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, cached)
+}
+
+// packageDirs returns directories of all non-standard packages the target depends on
+func (ctx *Context) packageDirs(target Target, platform Platform, settings BuildSettings) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	args := append([]string{}, goListDepsFlags...)
+	if len(settings.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(settings.Tags, " "))
+	}
+	args = append(args, "./"+filepath.Dir(filepath.Clean(target.Path)))
+	cmd := exec.Command("go", args...)
+	cmd.Dir = ctx.ProjectRoot()
+	cmd.Env = append(os.Environ(), settings.GoEnv(platform)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "failed to list dependencies of %s: %s", target.Name, strings.TrimSpace(stderr.String()))
+	}
+	var dirs []string
+	for _, dir := range strings.Split(stdout.String(), "\n") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// goEnv returns effective Go environment of the build (go env of the current process environment
+// along with variables of the build) skipping variables which differ between runs
+func (ctx *Context) goEnv(env []string) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", "env", "-json")
+	cmd.Dir = ctx.ProjectRoot()
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read go env: %s", strings.TrimSpace(stderr.String()))
+	}
+	vars := make(map[string]string)
+	if err := json.Unmarshal(stdout.Bytes(), &vars); err != nil {
+		return nil, errors.Wrap(err, "failed to parse go env")
+	}
+	var res []string
+	for name, value := range vars {
+		if !cacheVolatileGoEnv[name] {
+			res = append(res, name+"="+value)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func currentGoVersion() (string, error) {
+	goVersionOnce.Do(func() {
+		out, err := exec.Command("go", "version").Output()
+		goVersion, goVersionErr = strings.TrimSpace(string(out)), err
+	})
+	return goVersion, goVersionErr
+}
+
+// hashSources hashes names and contents of source files in the directory (tests are skipped)
+func hashSources(w io.Writer, root string, dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if relDir, err := filepath.Rel(root, dir); err == nil {
+		fmt.Fprintf(w, "dir: %s\n", filepath.ToSlash(relDir))
+	} else {
+		fmt.Fprintf(w, "dir: %s\n", dir)
+	}
+	for _, file := range files {
+		if file.IsDir() || !isSourceFile(file.Name()) {
+			continue
+		}
+		if err := hashFile(w, filepath.Join(dir, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hashFile(w io.Writer, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fmt.Fprintf(w, "file: %s\n", filepath.Base(filePath))
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func isSourceFile(name string) bool {
+	if strings.HasSuffix(name, "_test.go") {
+		return false
+	}
+	for _, ext := range cacheSourceExts {
+		if filepath.Ext(name) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func copyFile(from string, to string, perm os.FileMode) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(to, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
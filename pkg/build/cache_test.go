@@ -0,0 +1,109 @@
+package build_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/smecsia/go-utils/pkg/git/mock"
+)
+
+func TestBuildCache(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "cache")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	projectDir := path.Join(tmpDir, "project")
+	writeFile := func(name string, content string) {
+		Expect(os.MkdirAll(path.Dir(path.Join(projectDir, name)), os.ModePerm)).To(BeNil())
+		Expect(ioutil.WriteFile(path.Join(projectDir, name), []byte(content), 0644)).To(BeNil())
+	}
+	writeFile("build.yaml", "outDir: bin\n")
+	writeFile("go.mod", "module example.com/app\n\ngo 1.12\n")
+	writeFile("cmd/app/main.go", "package main\n\nvar Version string\n\nfunc main() { println(Version) }\n")
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(projectDir)).To(BeNil())
+
+	gitMock := &mock.GitMock{}
+	gitMock.On("HashShort").Return("abc123", nil)
+	gitMock.On("Hash").Return("abc123def456", nil)
+	gitMock.On("CurrentBranch").Return("master", nil)
+	gitMock.On("IsWorkTreeClean").Return(true, "", nil)
+	ctx := &Context{OutDir: "bin", Version: "1.0.0", Parallel: "false", CacheDir: path.Join(tmpDir, "cache")}
+	ctx.SetGit(gitMock)
+	target := Target{Name: "app", Path: "cmd/app/main.go"}
+	platform := Platform{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}
+	settings := BuildSettings{LdFlags: "-X main.Version=1.0.0-abc123"}
+	otherSettings := BuildSettings{LdFlags: "-X main.Version=1.0.1-abc123"}
+
+	key, err := ctx.BuildCacheKey(target, platform, settings)
+	Expect(err).To(BeNil())
+	writeFile("README.md", "docs only")
+	writeFile("cmd/app/main_test.go", "package main\n")
+	Expect(ctx.BuildCacheKey(target, platform, settings)).To(Equal(key))
+	Expect(ctx.BuildCacheKey(target, platform, otherSettings)).NotTo(Equal(key))
+	Expect(ctx.BuildCacheKey(target, Platform{GOOS: "plan9", GOARCH: "386"}, settings)).NotTo(Equal(key))
+	Expect(ctx.BuildCacheKey(target, platform, BuildSettings{LdFlags: settings.LdFlags, Tags: []string{"netgo"}})).NotTo(Equal(key))
+
+	Expect(ctx.Build(target, platform)).To(BeNil())
+	_, err = os.Stat(ctx.CachedArtifact(key))
+	Expect(err).To(BeNil())
+
+	outFile := ctx.OutFile(target, platform)
+	Expect(os.Remove(outFile)).To(BeNil())
+	Expect(ctx.Build(target, platform)).To(BeNil())
+	_, err = os.Stat(outFile)
+	Expect(err).To(BeNil())
+
+	// build date doesn't invalidate cached artifact while metadata of another commit does
+	cached := func() []string {
+		files, err := filepath.Glob(path.Join(tmpDir, "cache", "*", "*"))
+		Expect(err).To(BeNil())
+		return files
+	}
+	Expect(cached()).To(HaveLen(1))
+	sameCommit := &Context{OutDir: "bin", Version: "1.0.0", Parallel: "false", CacheDir: ctx.CacheDir, SourceDateEpoch: "1577836800"}
+	sameCommit.SetGit(gitMock)
+	Expect(sameCommit.Build(target, platform)).To(BeNil())
+	Expect(cached()).To(HaveLen(1))
+	otherGitMock := &mock.GitMock{}
+	otherGitMock.On("HashShort").Return("fed321", nil)
+	otherGitMock.On("Hash").Return("fed321cba654", nil)
+	otherGitMock.On("CurrentBranch").Return("master", nil)
+	otherGitMock.On("IsWorkTreeClean").Return(true, "", nil)
+	otherCommit := &Context{OutDir: "bin", Version: "1.0.0", Parallel: "false", CacheDir: ctx.CacheDir}
+	otherCommit.SetGit(otherGitMock)
+	Expect(otherCommit.Build(target, platform)).To(BeNil())
+	Expect(cached()).To(HaveLen(2))
+
+	// another commit which doesn't change sources reuses artifact if git hash is not linked into the binary
+	plain := func(git *mock.GitMock) *Context {
+		res := &Context{OutDir: "bin", Version: "1.0.0", Parallel: "false", CacheDir: ctx.CacheDir,
+			VersionFormat: VersionFormatPlain, BuildInfoPackage: "-"}
+		res.SetGit(git)
+		return res
+	}
+	Expect(plain(gitMock).Build(target, platform)).To(BeNil())
+	Expect(cached()).To(HaveLen(3))
+	Expect(plain(otherGitMock).Build(target, platform)).To(BeNil())
+	Expect(cached()).To(HaveLen(3))
+
+	// environment of the process affects the key along with the environment of the build
+	defer os.Setenv("CGO_ENABLED", os.Getenv("CGO_ENABLED"))
+	Expect(os.Setenv("CGO_ENABLED", "0")).To(BeNil())
+	noCgoKey, err := ctx.BuildCacheKey(target, platform, settings)
+	Expect(err).To(BeNil())
+	Expect(os.Setenv("CGO_ENABLED", "1")).To(BeNil())
+	Expect(ctx.BuildCacheKey(target, platform, settings)).NotTo(Equal(noCgoKey))
+
+	writeFile("cmd/app/main.go", "package main\n\nvar Version string\n\nfunc main() { println(\"v\" + Version) }\n")
+	Expect(ctx.BuildCacheKey(target, platform, settings)).NotTo(Equal(key))
+}
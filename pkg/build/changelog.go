@@ -0,0 +1,313 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/smecsia/go-utils/pkg/git"
+	"github.com/smecsia/go-utils/pkg/render"
+)
+
+const (
+	DefaultChangelogTemplate     = "template://changelog/default.tpl"
+	DefaultChangelogFile         = "CHANGELOG.md"
+	DefaultChangelogIssuePattern = `[A-Z][A-Z0-9]+-[0-9]+`
+
+	otherChangesTitle = "Other Changes"
+)
+
+var (
+	// changelogSections are titles of commit types in the order of sections, other types follow them
+	changelogSections = []struct{ commitType, title string }{
+		{"feat", "Features"},
+		{"fix", "Bug Fixes"},
+		{"perf", "Performance Improvements"},
+		{"refactor", "Code Refactoring"},
+		{"revert", "Reverts"},
+		{"docs", "Documentation"},
+		{"build", "Build System"},
+		{"ci", "Continuous Integration"},
+		{"test", "Tests"},
+		{"style", "Styles"},
+		{"chore", "Chores"},
+	}
+)
+
+// ChangelogConfig defines how changelog is generated
+type ChangelogConfig struct {
+	File         string `yaml:"file,omitempty" description:"Changelog file relative to project root (CHANGELOG.md by default)" example:"docs/CHANGELOG.md"`
+	Template     string `yaml:"template,omitempty" description:"Template of changelog (template://changelog/default.tpl by default)"`
+	IssuePattern string `yaml:"issuePattern,omitempty" description:"Regular expression of issue keys in commit messages (Jira keys by default)" example:"#[0-9]+"`
+	IssueURL     string `yaml:"issueURL,omitempty" description:"URL of the issue where %s is replaced with the key (without leading #)" example:"https://jira.example.com/browse/%s"`
+}
+
+// Changelog describes changes made between two revisions
+type Changelog struct {
+	Version  string
+	Date     string
+	From     string
+	To       string
+	Breaking []ChangelogEntry
+	Sections []ChangelogSection
+}
+
+// ChangelogSection groups changes of the same type by scope
+type ChangelogSection struct {
+	Type   string
+	Title  string
+	Scopes []ChangelogScope
+}
+
+// ChangelogScope groups changes of the same scope (empty for changes without scope)
+type ChangelogScope struct {
+	Scope   string
+	Entries []ChangelogEntry
+}
+
+// ChangelogEntry describes single change
+type ChangelogEntry struct {
+	Hash        string
+	ShortHash   string
+	Scope       string
+	Description string
+	Breaking    bool
+	Issues      []Issue
+}
+
+// Issue is the issue referenced by commit
+type Issue struct {
+	Key string
+	URL string
+}
+
+// Validate checks that issue pattern is valid
+func (c *ChangelogConfig) Validate() error {
+	if c.IssuePattern != "" {
+		if _, err := regexp.Compile(c.IssuePattern); err != nil {
+			return errors.Wrapf(err, "invalid issue pattern %q", c.IssuePattern)
+		}
+	}
+	if c.IssueURL != "" && strings.Count(c.IssueURL, "%s") != 1 {
+		return fmt.Errorf("issue URL %q must contain single %%s", c.IssueURL)
+	}
+	return nil
+}
+
+// GenerateChangelog returns changes of the version made after revision from (the last version tag by default)
+// up to revision to (HEAD by default), commits are grouped by type and scope
+func (ctx *Context) GenerateChangelog(from string, to string, version string) (Changelog, error) {
+	commits, tag, err := ctx.commitsSince(from, to)
+	if err != nil {
+		return Changelog{}, err
+	}
+	date, err := ctx.BuildDate()
+	if err != nil {
+		return Changelog{}, err
+	}
+	res := Changelog{Version: version, Date: date.Format("2006-01-02"), From: from, To: to}
+	if res.From == "" {
+		res.From = tag.name
+	}
+	if res.To == "" {
+		res.To = "HEAD"
+	}
+	issuePattern, err := regexp.Compile(ctx.changelogIssuePattern())
+	if err != nil {
+		return res, errors.Wrapf(err, "invalid issue pattern %q", ctx.Changelog.IssuePattern)
+	}
+	sections := make(map[string]*ChangelogSection)
+	// commits are listed newest first while changelog lists them in order they were made
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		if strings.HasPrefix(commit.Message, "Merge ") {
+			continue
+		}
+		parsed, ok := ParseConventionalCommit(commit)
+		if !ok {
+			parsed = ConventionalCommit{Hash: commit.Hash, Description: strings.TrimSpace(strings.SplitN(commit.Message, "\n", 2)[0])}
+		}
+		entry := ctx.changelogEntry(commit, parsed, issuePattern)
+		if entry.Breaking {
+			res.Breaking = append(res.Breaking, entry)
+		}
+		section, ok := sections[parsed.Type]
+		if !ok {
+			section = &ChangelogSection{Type: parsed.Type, Title: changelogTitle(parsed.Type)}
+			sections[parsed.Type] = section
+		}
+		section.add(entry)
+	}
+	for _, section := range sections {
+		sort.SliceStable(section.Scopes, func(i, j int) bool { return section.Scopes[i].Scope < section.Scopes[j].Scope })
+		res.Sections = append(res.Sections, *section)
+	}
+	sort.Slice(res.Sections, func(i, j int) bool {
+		return changelogOrder(res.Sections[i].Type) < changelogOrder(res.Sections[j].Type) ||
+			changelogOrder(res.Sections[i].Type) == changelogOrder(res.Sections[j].Type) && res.Sections[i].Type < res.Sections[j].Type
+	})
+	return res, nil
+}
+
+// WriteChangelog renders changelog using template of the config
+func (ctx *Context) WriteChangelog(w io.Writer, changelog Changelog) error {
+	tpl := ctx.Changelog.Template
+	if tpl == "" {
+		tpl = DefaultChangelogTemplate
+	}
+	return render.Write(w, tpl, changelog)
+}
+
+// ChangelogFile returns path to the changelog file
+func (ctx *Context) ChangelogFile() string {
+	if ctx.Changelog.File != "" {
+		return ctx.Path(ctx.Changelog.File)
+	}
+	return ctx.Path(DefaultChangelogFile)
+}
+
+// PrependChangelog renders changelog at the beginning of the changelog file (after its title if there is one)
+func (ctx *Context) PrependChangelog(changelog Changelog) error {
+	var rendered bytes.Buffer
+	if err := ctx.WriteChangelog(&rendered, changelog); err != nil {
+		return err
+	}
+	file := ctx.ChangelogFile()
+	existing, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var res bytes.Buffer
+	content := string(existing)
+	if strings.HasPrefix(content, "# ") {
+		parts := strings.SplitN(content, "\n", 2)
+		res.WriteString(parts[0] + "\n\n")
+		content = ""
+		if len(parts) > 1 {
+			content = strings.TrimLeft(parts[1], "\n")
+		}
+	}
+	res.Write(bytes.TrimRight(rendered.Bytes(), "\n"))
+	res.WriteString("\n")
+	if content != "" {
+		res.WriteString("\n" + content)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, res.Bytes(), 0644)
+}
+
+// Release bumps version according to conventional commits, prepends changelog of the new version
+// to the changelog file and commits both pushing the commit to the remote (if version is taken from git,
+// only changelog is committed and the commit is tagged with the new version)
+func (ctx *Context) Release() (VersionBump, error) {
+	bump, err := ctx.NextVersion()
+	if err != nil {
+		return bump, err
+	}
+	if bump.Tag != "" && bump.Level == BumpNone {
+		return bump, errors.Errorf("no changes to release since %s", bump.Tag)
+	}
+	changelog, err := ctx.GenerateChangelog("", "", bump.Version)
+	if err != nil {
+		return bump, err
+	}
+	if !ctx.IsGitVersion() && bump.Version != ctx.Version {
+		if err := ctx.SetVersionInConfig(bump.Version); err != nil {
+			return bump, err
+		}
+	}
+	if err := ctx.PrependChangelog(changelog); err != nil {
+		return bump, err
+	}
+	file, err := filepath.Rel(ctx.gitClient().Root(), ctx.ChangelogFile())
+	if err != nil {
+		return bump, err
+	}
+	if err := ctx.gitClient().Add(filepath.ToSlash(file)); err != nil {
+		return bump, err
+	}
+	message := fmt.Sprintf("Release %s", bump.Version)
+	if err := ctx.GitCommitAndPush(message); err != nil {
+		return bump, err
+	}
+	if ctx.IsGitVersion() {
+		if _, err := ctx.TagVersion(bump.Version, message); err != nil {
+			return bump, err
+		}
+	}
+	ctx.Version = bump.Version
+	return bump, nil
+}
+
+func (ctx *Context) changelogIssuePattern() string {
+	if ctx.Changelog.IssuePattern != "" {
+		return ctx.Changelog.IssuePattern
+	}
+	return DefaultChangelogIssuePattern
+}
+
+func (ctx *Context) changelogEntry(commit git.Commit, parsed ConventionalCommit, issuePattern *regexp.Regexp) ChangelogEntry {
+	entry := ChangelogEntry{Hash: commit.Hash, ShortHash: commit.Hash, Scope: parsed.Scope,
+		Description: parsed.Description, Breaking: parsed.Breaking}
+	if len(entry.ShortHash) > 7 {
+		entry.ShortHash = entry.ShortHash[:7]
+	}
+	seen := make(map[string]bool)
+	for _, key := range issuePattern.FindAllString(commit.Message, -1) {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		issue := Issue{Key: key}
+		if ctx.Changelog.IssueURL != "" {
+			issue.URL = fmt.Sprintf(ctx.Changelog.IssueURL, strings.TrimPrefix(key, "#"))
+		}
+		entry.Issues = append(entry.Issues, issue)
+	}
+	return entry
+}
+
+// add adds entry to the group of its scope
+func (section *ChangelogSection) add(entry ChangelogEntry) {
+	for i := range section.Scopes {
+		if section.Scopes[i].Scope == entry.Scope {
+			section.Scopes[i].Entries = append(section.Scopes[i].Entries, entry)
+			return
+		}
+	}
+	section.Scopes = append(section.Scopes, ChangelogScope{Scope: entry.Scope, Entries: []ChangelogEntry{entry}})
+}
+
+func changelogTitle(commitType string) string {
+	for _, section := range changelogSections {
+		if section.commitType == commitType {
+			return section.title
+		}
+	}
+	if commitType == "" {
+		return otherChangesTitle
+	}
+	return strings.Title(commitType)
+}
+
+// changelogOrder returns position of the section, unknown types follow known ones and other changes are the last
+func changelogOrder(commitType string) int {
+	for i, section := range changelogSections {
+		if section.commitType == commitType {
+			return i
+		}
+	}
+	if commitType == "" {
+		return len(changelogSections) + 1
+	}
+	return len(changelogSections)
+}
@@ -0,0 +1,97 @@
+package build_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/smecsia/go-utils/pkg/git"
+	gogit "gopkg.in/src-d/go-git.v4"
+)
+
+// noPushGit records commit message instead of committing and pushing
+type noPushGit struct {
+	git.Git
+	message string
+}
+
+func (g *noPushGit) CommitAndPush(msg string) error {
+	g.message = msg
+	return nil
+}
+
+func TestChangelog(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "changelog")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	Expect(ioutil.WriteFile(path.Join(tmpDir, "build.yaml"), []byte("version: 1.0.0\n"), 0644)).To(BeNil())
+	Expect(ioutil.WriteFile(path.Join(tmpDir, "CHANGELOG.md"), []byte("# Changelog\n\n## 1.0.0 (2019-12-31)\n"), 0644)).To(BeNil())
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(tmpDir)).To(BeNil())
+
+	repo := newTestRepo(tmpDir)
+	gitClient := &noPushGit{Git: git.New(tmpDir)}
+	ctx := &Context{Version: "1.0.0", AuditLog: "-", SourceDateEpoch: "1577836800",
+		Changelog: ChangelogConfig{IssueURL: "https://jira.example.com/browse/%s"}}
+	ctx.SetConfigFilePath(path.Join(tmpDir, "build.yaml"))
+	ctx.SetGit(gitClient)
+
+	repo.tag("v1.0.0", repo.commit("feat: initial version"), false)
+	repo.commit("fix(config): handle empty config\n\nRefs APP-12")
+	first := repo.commit("feat(api): add endpoint")
+	repo.commit("Merge branch 'feature'")
+	repo.commit("feat(api)!: remove deprecated endpoint\n\nFixes APP-7 and APP-7")
+	repo.commit("feat: add cli")
+	repo.commit("update readme")
+
+	changelog, err := ctx.GenerateChangelog("", "", "2.0.0")
+	Expect(err).To(BeNil())
+	Expect(changelog.From).To(Equal("v1.0.0"))
+	Expect(changelog.To).To(Equal("HEAD"))
+	Expect(changelog.Date).To(Equal("2020-01-01"))
+	Expect(changelog.Breaking).To(HaveLen(1))
+	Expect(changelog.Breaking[0].Issues).To(Equal([]Issue{{Key: "APP-7", URL: "https://jira.example.com/browse/APP-7"}}))
+	Expect(changelog.Sections).To(HaveLen(3))
+	features := changelog.Sections[0]
+	Expect(features.Title).To(Equal("Features"))
+	Expect(features.Scopes).To(HaveLen(2))
+	Expect(features.Scopes[0].Scope).To(BeEmpty())
+	Expect(features.Scopes[1].Scope).To(Equal("api"))
+	Expect(features.Scopes[1].Entries[0].Description).To(Equal("add endpoint"))
+	Expect(features.Scopes[1].Entries[1].Description).To(Equal("remove deprecated endpoint"))
+	Expect(changelog.Sections[1].Title).To(Equal("Bug Fixes"))
+	Expect(changelog.Sections[2].Title).To(Equal("Other Changes"))
+
+	var out bytes.Buffer
+	Expect(ctx.WriteChangelog(&out, changelog)).To(BeNil())
+	Expect(out.String()).To(HavePrefix("## 2.0.0 (2020-01-01)\n\n### BREAKING CHANGES\n\n* **api:** remove deprecated endpoint\n"))
+	Expect(out.String()).To(ContainSubstring("* **config:** handle empty config ([APP-12](https://jira.example.com/browse/APP-12)) ("))
+	Expect(out.String()).To(ContainSubstring("### Other Changes\n\n* update readme ("))
+
+	changelog, err = ctx.GenerateChangelog(first.String(), "HEAD~1", "2.0.0")
+	Expect(err).To(BeNil())
+	Expect(changelog.Breaking).To(HaveLen(1))
+	Expect(changelog.Sections).To(HaveLen(1))
+
+	bump, err := ctx.Release()
+	Expect(err).To(BeNil())
+	Expect(bump.Version).To(Equal("2.0.0"))
+	Expect(gitClient.message).To(Equal("Release 2.0.0"))
+	content, err := ioutil.ReadFile(path.Join(tmpDir, "CHANGELOG.md"))
+	Expect(err).To(BeNil())
+	Expect(string(content)).To(HavePrefix("# Changelog\n\n## 2.0.0 (2020-01-01)\n"))
+	Expect(string(content)).To(HaveSuffix("\n\n## 1.0.0 (2019-12-31)\n"))
+	wt, err := repo.repo.Worktree()
+	Expect(err).To(BeNil())
+	status, err := wt.Status()
+	Expect(err).To(BeNil())
+	Expect(status.File("CHANGELOG.md").Staging).To(Equal(gogit.Added))
+}
@@ -0,0 +1,162 @@
+package build
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/smecsia/go-utils/pkg/config"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+)
+
+var (
+	ChecksumAlgorithms = []string{ChecksumSHA256, ChecksumSHA512}
+)
+
+// Bundles returns bundles of all active targets for their active platforms
+func (ctx *Context) Bundles() []Bundle {
+	var res []Bundle
+	for _, target := range ctx.ActiveTargets() {
+		for _, platform := range ctx.ActiveTargetPlatforms(target) {
+			res = append(res, ctx.Bundle(target, platform))
+		}
+	}
+	return res
+}
+
+// ChecksumsFile returns path to the checksum manifest of algorithm (e.g. OutDir/SHA256SUMS)
+func (ctx *Context) ChecksumsFile(algorithm string) string {
+	return filepath.Join(ctx.OutPath(), strings.ToUpper(algorithm)+"SUMS")
+}
+
+// ActiveChecksums returns checksum algorithms of manifests (sha256 unless configured)
+func (ctx *Context) ActiveChecksums() []string {
+	if len(ctx.Checksums) == 0 {
+		return []string{ChecksumSHA256}
+	}
+	return ctx.Checksums
+}
+
+// WriteChecksums writes checksum manifests covering files of bundles (in format of sha256sum -c, paths are
+// relative to OutDir) and signs them with minisign signature if signing key is set (valid signatures of unchanged
+// manifests are kept), returns written files
+func (ctx *Context) WriteChecksums(bundles []Bundle) ([]string, error) {
+	files := make([]string, len(bundles))
+	for i, bundle := range bundles {
+		files[i] = bundle.BinaryFile
+	}
+	var privateKey ed25519.PrivateKey
+	if ctx.SigningKey != "" {
+		key, err := config.ReadPrivateKey(ctx.SigningKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read signing key %s", ctx.SigningKey)
+		}
+		privateKey = key
+	}
+	var res []string
+	for _, algorithm := range ctx.ActiveChecksums() {
+		var manifest bytes.Buffer
+		if err := WriteChecksums(&manifest, algorithm, ctx.OutPath(), files); err != nil {
+			return res, err
+		}
+		manifestFile := ctx.ChecksumsFile(algorithm)
+		existing, err := ioutil.ReadFile(manifestFile)
+		unchanged := err == nil && bytes.Equal(existing, manifest.Bytes())
+		if !unchanged {
+			if err := ioutil.WriteFile(manifestFile, manifest.Bytes(), 0644); err != nil {
+				return res, err
+			}
+		}
+		res = append(res, manifestFile)
+		if privateKey == nil {
+			continue
+		}
+		// valid signature of unchanged manifest is kept so that re-running a release (e.g. publishing
+		// after a partial failure) produces the same files
+		if signature, err := ioutil.ReadFile(manifestFile + MinisignFileExt); err == nil &&
+			VerifyMinisign(manifest.Bytes(), signature, privateKey.Public().(ed25519.PublicKey)) == nil {
+			res = append(res, manifestFile+MinisignFileExt)
+			continue
+		}
+		timestamp, err := ctx.BuildDate()
+		if err != nil {
+			return res, err
+		}
+		var signature bytes.Buffer
+		if err := WriteMinisign(&signature, manifest.Bytes(), filepath.Base(manifestFile), timestamp, privateKey); err != nil {
+			return res, err
+		}
+		if err := ioutil.WriteFile(manifestFile+MinisignFileExt, signature.Bytes(), 0644); err != nil {
+			return res, err
+		}
+		res = append(res, manifestFile+MinisignFileExt)
+	}
+	return res, nil
+}
+
+// WriteChecksums writes checksums of files one per line sorted by path relative to base dir
+// (format of coreutils, e.g. sha256sum)
+func WriteChecksums(w io.Writer, algorithm string, baseDir string, files []string) error {
+	paths := make(map[string]string, len(files))
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		relPath, err := filepath.Rel(baseDir, file)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(relPath)
+		paths[name] = file
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sum, err := fileChecksum(algorithm, paths[name])
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, checksumLine(sum, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileChecksum returns hex-encoded checksum of the file
+func fileChecksum(algorithm string, file string) (string, error) {
+	var hasher hash.Hash
+	switch algorithm {
+	case ChecksumSHA256:
+		hasher = sha256.New()
+	case ChecksumSHA512:
+		hasher = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported checksum algorithm %q, expected one of %v", algorithm, ChecksumAlgorithms)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
+
+func checksumLine(sum string, name string) string {
+	return fmt.Sprintf("%s  %s\n", sum, name)
+}
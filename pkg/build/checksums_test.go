@@ -0,0 +1,83 @@
+package build_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/smecsia/go-utils/pkg/config"
+)
+
+func TestWriteChecksums(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "checksums")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	writeFile := func(name string, content string) {
+		Expect(os.MkdirAll(path.Dir(path.Join(tmpDir, name)), os.ModePerm)).To(BeNil())
+		Expect(ioutil.WriteFile(path.Join(tmpDir, name), []byte(content), 0644)).To(BeNil())
+	}
+	writeFile("build.yaml", "outDir: bin\n")
+	writeFile("bin/linux-amd64/app-linux-amd64.tar.gz", "linux")
+	writeFile("bin/darwin-amd64/app-darwin-amd64.tar.gz", "darwin")
+	keyFile := path.Join(tmpDir, "build.key")
+	Expect(config.GenerateSigningKeys(keyFile, keyFile+".pub")).To(BeNil())
+	publicKey, err := config.ReadPublicKey(keyFile + ".pub")
+	Expect(err).To(BeNil())
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(tmpDir)).To(BeNil())
+
+	ctx := &Context{OutDir: "bin", FilterTargets: "-", FilterPlatforms: "-", SourceDateEpoch: "1500000000",
+		Checksums: []string{"sha256", "sha512"}, SigningKey: keyFile,
+		Targets:   []Target{{Name: "app", Path: "cmd/app/main.go"}},
+		Platforms: []Platform{{GOOS: "linux", GOARCH: "amd64"}, {GOOS: "darwin", GOARCH: "amd64"}}}
+	files, err := ctx.WriteChecksums(ctx.Bundles())
+	Expect(err).To(BeNil())
+	Expect(files).To(Equal([]string{
+		path.Join(tmpDir, "bin", "SHA256SUMS"), path.Join(tmpDir, "bin", "SHA256SUMS.minisig"),
+		path.Join(tmpDir, "bin", "SHA512SUMS"), path.Join(tmpDir, "bin", "SHA512SUMS.minisig"),
+	}))
+
+	manifest, err := ioutil.ReadFile(ctx.ChecksumsFile(ChecksumSHA256))
+	Expect(err).To(BeNil())
+	Expect(string(manifest)).To(Equal(fmt.Sprintf("%x  darwin-amd64/app-darwin-amd64.tar.gz\n%x  linux-amd64/app-linux-amd64.tar.gz\n",
+		sha256.Sum256([]byte("darwin")), sha256.Sum256([]byte("linux")))))
+
+	signature, err := ioutil.ReadFile(ctx.ChecksumsFile(ChecksumSHA256) + MinisignFileExt)
+	Expect(err).To(BeNil())
+	Expect(string(signature)).To(ContainSubstring("trusted comment: timestamp:1500000000\tfile:SHA256SUMS\thashed\n"))
+	Expect(VerifyMinisign(manifest, signature, publicKey)).To(BeNil())
+	Expect(VerifyMinisign(append(manifest, '\n'), signature, publicKey)).NotTo(BeNil())
+	Expect(VerifyMinisign(manifest, bytes.Replace(signature, []byte("timestamp:1500000000"), []byte("timestamp:1600000000"), 1), publicKey)).NotTo(BeNil())
+
+	// signature of unchanged manifest is kept while changed manifest is signed again
+	ctx.SourceDateEpoch = ""
+	_, err = ctx.WriteChecksums(ctx.Bundles())
+	Expect(err).To(BeNil())
+	Expect(ioutil.ReadFile(ctx.ChecksumsFile(ChecksumSHA256) + MinisignFileExt)).To(Equal(signature))
+	writeFile("bin/linux-amd64/app-linux-amd64.tar.gz", "linux v2")
+	_, err = ctx.WriteChecksums(ctx.Bundles())
+	Expect(err).To(BeNil())
+	changed, err := ioutil.ReadFile(ctx.ChecksumsFile(ChecksumSHA256) + MinisignFileExt)
+	Expect(err).To(BeNil())
+	Expect(string(changed)).NotTo(ContainSubstring("timestamp:1500000000"))
+	manifest, err = ioutil.ReadFile(ctx.ChecksumsFile(ChecksumSHA256))
+	Expect(err).To(BeNil())
+	Expect(VerifyMinisign(manifest, changed, publicKey)).To(BeNil())
+
+	var publicKeyFile bytes.Buffer
+	Expect(WriteMinisignPublicKey(&publicKeyFile, publicKey)).To(BeNil())
+	Expect(publicKeyFile.String()).To(HavePrefix("untrusted comment: minisign public key "))
+
+	ctx.Checksums = []string{"md5"}
+	Expect(ctx.Validate()).NotTo(BeNil())
+}
@@ -0,0 +1,100 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	stderrTailSize = 4096
+)
+
+// ExitError describes command which has failed or was cancelled
+type ExitError struct {
+	Command  string
+	ExitCode int
+	// Stderr is the tail of command's stderr output
+	Stderr string
+	Err    error
+}
+
+// Error returns description of the failure along with the tail of stderr
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("Failed to execute '%s': %s", e.Command, e.Err)
+	if e.Stderr != "" {
+		msg += "\n" + e.Stderr
+	}
+	return msg
+}
+
+// String returns printable form of the command
+func (cmd Cmd) String() string {
+	if len(cmd.Args) == 0 {
+		return fmt.Sprintf("bash \"-c\" \"%s\"", cmd.Command)
+	}
+	args := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`") {
+			arg = strconv.Quote(arg)
+		}
+		args[i] = arg
+	}
+	return strings.Join(args, " ")
+}
+
+// command returns exec.Cmd along with its context and the cancel function releasing it
+func (cmd Cmd) command() (*exec.Cmd, context.Context, context.CancelFunc) {
+	ctx := cmd.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cancel := func() {}
+	if cmd.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cmd.Timeout)
+	}
+	if len(cmd.Args) == 0 {
+		return exec.CommandContext(ctx, "bash", "-c", cmd.Command), ctx, cancel
+	}
+	return exec.CommandContext(ctx, cmd.Args[0], cmd.Args[1:]...), ctx, cancel
+}
+
+// exitError converts error of finished command into ExitError
+func exitError(ctx context.Context, cmd Cmd, err error, stderr *tailWriter) error {
+	if err == nil {
+		return nil
+	}
+	res := &ExitError{Command: cmd.String(), ExitCode: -1, Stderr: stderr.String(), Err: err}
+	if ctx.Err() != nil {
+		res.Err = ctx.Err()
+	} else if exitErr, ok := err.(*exec.ExitError); ok {
+		res.ExitCode = exitErr.ExitCode()
+	}
+	return res
+}
+
+// tailWriter keeps last bytes written to it
+type tailWriter struct {
+	mutex sync.Mutex
+	size  int
+	buf   []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > w.size {
+		w.buf = w.buf[len(w.buf)-w.size:]
+	}
+	return len(p), nil
+}
+
+func (w *tailWriter) String() string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return strings.TrimSpace(string(w.buf))
+}
@@ -0,0 +1,57 @@
+package build_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+)
+
+func TestRunCmdArgs(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "cmd")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	ctx := &Context{}
+	file := path.Join(tmpDir, "file with 'quotes' and spaces")
+	Expect(ctx.RunCmd(Cmd{Args: []string{"touch", file}})).To(BeNil())
+	_, err = os.Stat(file)
+	Expect(err).To(BeNil())
+
+	Expect(ctx.RunCmd(Cmd{Command: `test -z "$HOME" && test "$FOO" = bar`, Env: []string{"FOO=bar"}, ReplaceEnv: true})).To(BeNil())
+	Expect(ctx.RunCmd(Cmd{Command: `test -n "$HOME"`, Env: []string{"FOO=bar"}})).To(BeNil())
+
+	var out bytes.Buffer
+	Expect(ctx.RunCmd(Cmd{Args: []string{"env"}, ReplaceEnv: true, Stdout: &out})).To(BeNil())
+	Expect(out.String()).To(Equal("Executing 'env'\n"))
+}
+
+func TestRunCmdExitError(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := &Context{}
+	err := ctx.RunCmd(Cmd{Args: []string{"sh", "-c", "echo boom >&2; exit 3"}})
+	Expect(err).To(BeAssignableToTypeOf(&ExitError{}))
+	exitErr := err.(*ExitError)
+	Expect(exitErr.ExitCode).To(Equal(3))
+	Expect(exitErr.Stderr).To(Equal("boom"))
+	Expect(exitErr.Command).To(Equal(`sh -c "echo boom >&2; exit 3"`))
+
+	err = ctx.RunCmd(Cmd{Args: []string{"sleep", "5"}, Timeout: 100 * time.Millisecond})
+	Expect(err).To(BeAssignableToTypeOf(&ExitError{}))
+	Expect(err.(*ExitError).Err).To(Equal(context.DeadlineExceeded))
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = ctx.RunCmd(Cmd{Command: "sleep 5", Context: cancelled})
+	Expect(err).To(BeAssignableToTypeOf(&ExitError{}))
+	Expect(err.(*ExitError).Err).To(Equal(context.Canceled))
+}
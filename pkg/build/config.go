@@ -1,10 +1,20 @@
 package build
 
 import (
+	"io"
+	"os"
+
 	"github.com/smecsia/go-utils/pkg/config"
 	"github.com/smecsia/go-utils/pkg/util"
 )
 
+const (
+	ConfigFileName     = "build.yaml"
+	ConfigFileEnv      = "BUILD_CONFIG"
+	ConfigPublicKeyEnv = "BUILD_CONFIG_PUBLIC_KEY"
+	ConfigChecksumEnv  = "BUILD_CONFIG_SHA256"
+)
+
 // Writes config file to yaml file
 func WriteConfigFile(filePath string, cfg *Context) error {
 	return config.WriteConfigFile(filePath, cfg)
@@ -16,7 +26,14 @@ func DefaultConfig() *Context {
 }
 
 // Reads config file from yaml safely and adds defaults from env or default tags
+// (panics if config signature or checksum is configured and config is missing or doesn't match)
 func Init(filePath string, reader util.ConsoleReader) *Context {
+	source, err := verifiedSource(config.NewFileSource(filePath))
+	if err != nil {
+		panic(err)
+	} else if _, ok := source.(*config.VerifiedSource); ok {
+		return config.InitSource(source, &Context{}, reader).(*Context)
+	}
 	return config.Init(filePath, &Context{}, reader).(*Context)
 }
 
@@ -25,3 +42,77 @@ func ReadConfigFile(filePath string) (*Context, map[string]interface{}, error) {
 	ctx, raw, err := config.ReadConfigFile(filePath, &Context{})
 	return ctx.(*Context), raw, err
 }
+
+// WriteSampleConfig writes fully commented sample config file with all keys and their defaults
+func WriteSampleConfig(w io.Writer) error {
+	return config.WriteSample(w, &Context{})
+}
+
+// DiffConfig returns difference between two config files, between config file and its effective state
+// (if toPath is empty) or between the same config file loaded with two env profiles (if profile paths are set)
+func DiffConfig(fromPath string, toPath string, fromProfilePath string, toProfilePath string) (config.Diff, error) {
+	newContext := func() config.Config { return &Context{} }
+	if fromProfilePath != "" || toProfilePath != "" {
+		fromProfile, err := readProfile(fromProfilePath)
+		if err != nil {
+			return config.Diff{}, err
+		}
+		toProfile, err := readProfile(toProfilePath)
+		if err != nil {
+			return config.Diff{}, err
+		}
+		return config.DiffProfiles(fromPath, fromProfile, toProfile, newContext)
+	}
+	if toPath == "" {
+		return config.DiffEffective(fromPath, newContext)
+	}
+	return config.DiffFiles(fromPath, toPath, newContext)
+}
+
+func readProfile(filePath string) (config.Profile, error) {
+	if filePath == "" {
+		return config.Profile{}, nil
+	}
+	return config.ReadProfile(filePath)
+}
+
+// DiscoverConfig finds config file (explicitly provided path, BUILD_CONFIG env variable or build.yaml
+// in the current dir or any of its parents) and loads it. Returns paths of the used config files
+func DiscoverConfig(filePath string) (*Context, []string, error) {
+	source, err := config.Discovery{
+		FileName:  ConfigFileName,
+		Path:      filePath,
+		EnvVar:    ConfigFileEnv,
+		Locations: []string{config.LocationExplicit, config.LocationEnv, config.LocationUpward},
+		Required:  true,
+	}.Discover()
+	if err != nil {
+		return nil, nil, err
+	}
+	verified, err := verifiedSource(source)
+	if err != nil {
+		return nil, source.Paths, err
+	}
+	cfg, err := config.Load(verified, &Context{})
+	if err != nil {
+		return nil, source.Paths, err
+	}
+	return cfg.(*Context), source.Paths, nil
+}
+
+// verifiedSource wraps source with verification of signature (BUILD_CONFIG_PUBLIC_KEY) and checksum (BUILD_CONFIG_SHA256)
+func verifiedSource(source config.Source) (config.Source, error) {
+	publicKeyFile, checksum := os.Getenv(ConfigPublicKeyEnv), os.Getenv(ConfigChecksumEnv)
+	if publicKeyFile == "" && checksum == "" {
+		return source, nil
+	}
+	verified := config.NewVerifiedSource(source, nil, checksum)
+	if publicKeyFile != "" {
+		publicKey, err := config.ReadPublicKey(publicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		verified.PublicKey = publicKey
+	}
+	return verified, nil
+}
@@ -0,0 +1,67 @@
+package build_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/smecsia/go-utils/pkg/config"
+)
+
+func TestInitVerifiesConfig(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "config")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	cfgFile := path.Join(tmpDir, "build.yaml")
+	content := []byte("outDir: bin\nversion: 1.0.0\n")
+	Expect(ioutil.WriteFile(cfgFile, content, 0644)).To(BeNil())
+	defer os.Unsetenv(ConfigChecksumEnv)
+	Expect(os.Setenv(ConfigChecksumEnv, config.Checksum(content))).To(BeNil())
+
+	Expect(Init(cfgFile, nil).Version).To(Equal("1.0.0"))
+
+	Expect(ioutil.WriteFile(cfgFile, []byte("outDir: bin\nversion: 6.6.6\n"), 0644)).To(BeNil())
+	Expect(func() { Init(cfgFile, nil) }).To(Panic())
+
+	// missing config must not bypass verification
+	Expect(os.Remove(cfgFile)).To(BeNil())
+	Expect(func() { Init(cfgFile, nil) }).To(Panic())
+
+	Expect(os.Unsetenv(ConfigChecksumEnv)).To(BeNil())
+	Expect(func() { Init(cfgFile, nil) }).NotTo(Panic())
+}
+
+type countingReader struct {
+	reads int
+}
+
+func (r *countingReader) ReadLine() (string, error) {
+	r.reads++
+	return "", nil
+}
+
+func (r *countingReader) ReadPassword() (string, error) {
+	r.reads++
+	return "", nil
+}
+
+func TestInitAsksNothingForOptionalFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "config")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	cfgFile := path.Join(tmpDir, "build.yaml")
+	Expect(ioutil.WriteFile(cfgFile, []byte("outDir: bin\nversion: 1.0.0\n"), 0644)).To(BeNil())
+
+	reader := &countingReader{}
+	ctx := Init(cfgFile, reader)
+	Expect(reader.reads).To(BeZero())
+	Expect(ctx.PublishPassword).To(BeEmpty())
+	Expect(ctx.AuditLog).To(BeEmpty())
+}
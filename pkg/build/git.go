@@ -4,7 +4,7 @@ import "fmt"
 
 // IsWorkTreeClean checks if a worktree is clean (no new changes have been produced)
 func (ctx *Context) GitCheckWorkTree() error {
-	if clean, status, err := ctx.git.IsWorkTreeClean(); err != nil {
+	if clean, status, err := ctx.gitClient().IsWorkTreeClean(); err != nil {
 		return err
 	} else if !clean {
 		return fmt.Errorf("git tree is not clean: \n%s", status)
@@ -14,5 +14,5 @@ func (ctx *Context) GitCheckWorkTree() error {
 
 // CommitAndPush makes commit and pushes to master
 func (ctx *Context) GitCommitAndPush(msg string) error {
-	return ctx.git.CommitAndPush(msg)
+	return ctx.gitClient().CommitAndPush(msg)
 }
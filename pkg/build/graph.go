@@ -0,0 +1,352 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smecsia/go-utils/pkg/render"
+)
+
+const (
+	GraphFormatDOT  = "dot"
+	GraphFormatJSON = "json"
+)
+
+var (
+	// errInputChanged stops walking inputs once changed one is found
+	errInputChanged = errors.New("input has changed")
+)
+
+// TaskConfig declares task of the task graph
+type TaskConfig struct {
+	Name        string   `yaml:"name" json:"name" description:"Name of the task" example:"generate"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty" description:"Description of the task"`
+	Deps        []string `yaml:"deps,omitempty" json:"deps,omitempty" description:"Tasks which must succeed before the task starts" example:"clean"`
+	Command     string   `yaml:"command,omitempty" json:"command,omitempty" description:"Command run in the sub shell (bash -c) from project root" example:"go generate ./..."`
+	Inputs      []string `yaml:"inputs,omitempty" json:"inputs,omitempty" description:"Files, directories or glob patterns (relative to project root) the task reads" example:"templates"`
+	Outputs     []string `yaml:"outputs,omitempty" json:"outputs,omitempty" description:"Files the task produces, the task is skipped if all of them are newer than its inputs" example:"pkg/render/templates.tpl.go"`
+}
+
+// Task is the node of the task graph, Run is called once all dependencies have succeeded
+// (tasks declared in config run their commands)
+type Task struct {
+	TaskConfig
+	Run func() error `yaml:"-" json:"-"`
+}
+
+// TaskGraph is the acyclic graph of tasks and their dependencies
+type TaskGraph struct {
+	tasks map[string]Task
+	names []string
+}
+
+// Validate checks that task has name
+func (t *TaskConfig) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("task name must not be empty")
+	}
+	return nil
+}
+
+// NewTaskGraph returns graph of tasks making sure that names are unique, all dependencies
+// are defined and there are no cycles
+func NewTaskGraph(tasks []Task) (*TaskGraph, error) {
+	res := &TaskGraph{tasks: make(map[string]Task)}
+	for _, task := range tasks {
+		if err := task.Validate(); err != nil {
+			return nil, err
+		} else if _, ok := res.tasks[task.Name]; ok {
+			return nil, fmt.Errorf("duplicate task name: %s", task.Name)
+		}
+		res.tasks[task.Name] = task
+		res.names = append(res.names, task.Name)
+	}
+	for _, name := range res.names {
+		for _, dep := range res.tasks[name].Deps {
+			if _, ok := res.tasks[dep]; !ok {
+				return nil, fmt.Errorf("task %s depends on unknown task %s", name, dep)
+			}
+		}
+	}
+	if _, err := res.Order(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Task returns task by its name
+func (g *TaskGraph) Task(name string) (Task, bool) {
+	task, ok := g.tasks[name]
+	return task, ok
+}
+
+// Order returns tasks along with all their dependencies so that every task follows its dependencies
+// (all tasks in order of declaration if names are empty)
+func (g *TaskGraph) Order(names ...string) ([]string, error) {
+	if len(names) == 0 {
+		names = g.names
+	}
+	var res []string
+	// visiting tasks are on the current path, visited ones are already in result
+	visiting, visited := make(map[string]bool), make(map[string]bool)
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		if visited[name] {
+			return nil
+		} else if visiting[name] {
+			cycle := append([]string{}, path[indexOf(path, name):]...)
+			return fmt.Errorf("task dependency cycle: %s", strings.Join(append(cycle, name), " -> "))
+		}
+		task, ok := g.tasks[name]
+		if !ok {
+			return fmt.Errorf("unknown task: %s", name)
+		}
+		visiting[name] = true
+		path = append(path, name)
+		for _, dep := range task.Deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		visiting[name], visited[name] = false, true
+		res = append(res, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// Write writes tasks (along with their dependencies) in DOT (edges go from dependency to dependent task)
+// or JSON format (list of tasks in order of execution)
+func (g *TaskGraph) Write(w io.Writer, format string, names ...string) error {
+	order, err := g.Order(names...)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case GraphFormatDOT:
+		fmt.Fprintln(w, "digraph tasks {")
+		for _, name := range order {
+			fmt.Fprintf(w, "  %q;\n", name)
+		}
+		for _, name := range order {
+			for _, dep := range g.tasks[name].Deps {
+				fmt.Fprintf(w, "  %q -> %q;\n", dep, name)
+			}
+		}
+		fmt.Fprintln(w, "}")
+		return nil
+	case GraphFormatJSON:
+		tasks := make([]TaskConfig, len(order))
+		for i, name := range order {
+			tasks[i] = g.tasks[name].TaskConfig
+		}
+		return render.Write(w, render.FormatJSON, tasks)
+	}
+	return fmt.Errorf("unsupported graph format %q, expected %s or %s", format, GraphFormatDOT, GraphFormatJSON)
+}
+
+// DefineTask adds task implemented in Go to the task graph
+func (ctx *Context) DefineTask(task Task) {
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+	ctx.definedTasks = append(ctx.definedTasks, task)
+}
+
+// TaskGraph returns graph of tasks declared in config followed by tasks defined in Go, tasks declared in config
+// under names of tasks defined in Go extend them (see Task.Extend)
+func (ctx *Context) TaskGraph() (*TaskGraph, error) {
+	tasksMutex.Lock()
+	defined := append([]Task{}, ctx.definedTasks...)
+	tasksMutex.Unlock()
+	var tasks []Task
+	extended := make(map[string]bool)
+	for _, cfg := range ctx.Tasks {
+		task := Task{TaskConfig: cfg}
+		if cfg.Command != "" {
+			task.Run = ctx.commandTask(cfg)
+		}
+		if i := taskIndex(defined, cfg.Name); i >= 0 && !extended[cfg.Name] {
+			defined[i], extended[cfg.Name] = defined[i].Extend(task), true
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	return NewTaskGraph(append(tasks, defined...))
+}
+
+// Extend returns copy of the task with dependencies, inputs and outputs of another task added to its own ones,
+// description and action of another task (if set) replace ones of the task
+func (t Task) Extend(other Task) Task {
+	res := t
+	res.Deps = appendMissing(append([]string{}, t.Deps...), other.Deps...)
+	res.Inputs = appendMissing(append([]string{}, t.Inputs...), other.Inputs...)
+	res.Outputs = appendMissing(append([]string{}, t.Outputs...), other.Outputs...)
+	if other.Description != "" {
+		res.Description = other.Description
+	}
+	if other.Run != nil {
+		res.Command, res.Run = other.Command, other.Run
+	}
+	return res
+}
+
+// RunTasks runs tasks along with their dependencies in parallel so that every task starts once all its
+// dependencies have succeeded (all tasks if names are empty, one by one unless parallel). Tasks which
+// outputs are newer than inputs are skipped, tasks depending on failed ones are cancelled
+func (ctx *Context) RunTasks(names ...string) error {
+	graph, err := ctx.TaskGraph()
+	if err != nil {
+		return err
+	}
+	order, err := graph.Order(names...)
+	if err != nil {
+		return err
+	}
+	return ctx.trackTasks(func(state *taskState) error {
+		errs := make([]error, len(order))
+		done := make(map[string]chan struct{}, len(order))
+		index := make(map[string]int, len(order))
+		for i, name := range order {
+			done[name], index[name] = make(chan struct{}), i
+		}
+		var wg sync.WaitGroup
+		for i, name := range order {
+			wg.Add(1)
+			go func(i int, task Task) {
+				defer wg.Done()
+				defer close(done[task.Name])
+				if i > 0 && !ctx.IsParallel() {
+					<-done[order[i-1]]
+				}
+				errs[i] = ctx.runGraphTask(state, task, func(dep string) error {
+					<-done[dep]
+					return errs[index[dep]]
+				})
+				if errs[i] != nil && !IsCancelled(errs[i]) && !ctx.IsKeepGoing() {
+					state.cancel()
+				}
+			}(i, graph.tasks[name])
+		}
+		wg.Wait()
+		return joinErrors(errs, ctx.IsKeepGoing())
+	})
+}
+
+// runGraphTask waits for dependencies and runs the task unless it is up to date
+func (ctx *Context) runGraphTask(state *taskState, task Task, wait func(dep string) error) error {
+	for _, dep := range task.Deps {
+		if err := wait(dep); err != nil {
+			err = errors.Wrapf(context.Canceled, "dependency %s has failed", dep)
+			state.finished(task.Name, err, nil)
+			return err
+		}
+	}
+	if err := state.ctx.Err(); err != nil {
+		state.finished(task.Name, err, nil)
+		return err
+	}
+	upToDate, err := ctx.IsTaskUpToDate(task.TaskConfig)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check whether task %s is up to date", task.Name)
+	}
+	if upToDate {
+		fmt.Println(fmt.Sprintf("[%s] up to date", task.Name))
+		return nil
+	}
+	if task.Run == nil {
+		return nil
+	}
+	return task.Run()
+}
+
+// IsTaskUpToDate returns true if task declares outputs and all of them exist and are newer than its inputs
+func (ctx *Context) IsTaskUpToDate(task TaskConfig) (bool, error) {
+	if len(task.Outputs) == 0 {
+		return false, nil
+	}
+	var oldest time.Time
+	for _, output := range task.Outputs {
+		info, err := os.Stat(ctx.Path(output))
+		if os.IsNotExist(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if oldest.IsZero() || info.ModTime().Before(oldest) {
+			oldest = info.ModTime()
+		}
+	}
+	for _, pattern := range task.Inputs {
+		matches, err := filepath.Glob(ctx.Path(pattern))
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid input pattern %q", pattern)
+		}
+		for _, match := range matches {
+			err := filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				} else if !info.IsDir() && info.ModTime().After(oldest) {
+					return errInputChanged
+				}
+				return nil
+			})
+			if err == errInputChanged {
+				return false, nil
+			} else if err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// commandTask returns action running command of the task from project root
+func (ctx *Context) commandTask(cfg TaskConfig) func() error {
+	return func() error {
+		return ctx.RunTask(cfg.Name, func(out *TaskOutput) error {
+			return ctx.RunCmd(Cmd{Command: cfg.Command, Wd: ctx.ProjectRoot(), Context: out.Context(),
+				Stdout: out.Stdout(), Stderr: out.Stderr()})
+		})
+	}
+}
+
+func taskIndex(tasks []Task, name string) int {
+	for i, task := range tasks {
+		if task.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func appendMissing(values []string, more ...string) []string {
+	for _, value := range more {
+		if indexOf(values, value) < 0 {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,227 @@
+package build_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+)
+
+func testTask(name string, run func() error, deps ...string) Task {
+	return Task{TaskConfig: TaskConfig{Name: name, Deps: deps}, Run: run}
+}
+
+func TestTaskGraphOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	graph, err := NewTaskGraph([]Task{
+		testTask("publish", nil, "checksums"),
+		testTask("checksums", nil, "bundles"),
+		testTask("bundles", nil, "build"),
+		testTask("build", nil, "generate", "test"),
+		testTask("test", nil, "generate"),
+		testTask("generate", nil),
+		testTask("lint", nil),
+	})
+	Expect(err).To(BeNil())
+	order, err := graph.Order()
+	Expect(err).To(BeNil())
+	Expect(order).To(Equal([]string{"generate", "test", "build", "bundles", "checksums", "publish", "lint"}))
+	order, err = graph.Order("build", "lint")
+	Expect(err).To(BeNil())
+	Expect(order).To(Equal([]string{"generate", "test", "build", "lint"}))
+	_, err = graph.Order("deploy")
+	Expect(err).To(MatchError("unknown task: deploy"))
+
+	_, err = NewTaskGraph([]Task{testTask("a", nil, "b"), testTask("b", nil, "c"), testTask("c", nil, "b")})
+	Expect(err).To(MatchError("task dependency cycle: b -> c -> b"))
+	_, err = NewTaskGraph([]Task{testTask("a", nil, "deployments")})
+	Expect(err).To(MatchError("task a depends on unknown task deployments"))
+	_, err = NewTaskGraph([]Task{testTask("a", nil), testTask("a", nil)})
+	Expect(err).To(MatchError("duplicate task name: a"))
+	_, err = NewTaskGraph([]Task{testTask("", nil)})
+	Expect(err).To(MatchError("task name must not be empty"))
+}
+
+func TestTaskGraphWrite(t *testing.T) {
+	RegisterTestingT(t)
+
+	graph, err := NewTaskGraph([]Task{testTask("build", nil, "generate"), testTask("generate", nil)})
+	Expect(err).To(BeNil())
+
+	var buf bytes.Buffer
+	Expect(graph.Write(&buf, GraphFormatDOT)).To(BeNil())
+	Expect(buf.String()).To(Equal("digraph tasks {\n  \"generate\";\n  \"build\";\n  \"generate\" -> \"build\";\n}\n"))
+
+	buf.Reset()
+	Expect(graph.Write(&buf, GraphFormatJSON, "generate")).To(BeNil())
+	Expect(buf.String()).To(MatchJSON(`[{"name": "generate"}]`))
+
+	Expect(graph.Write(&buf, "svg")).To(MatchError(ContainSubstring("unsupported graph format")))
+}
+
+func TestRunTasks(t *testing.T) {
+	RegisterTestingT(t)
+
+	var mutex sync.Mutex
+	var started []string
+	running, maxRunning := 0, 0
+	run := func(name string) func() error {
+		return func() error {
+			mutex.Lock()
+			started = append(started, name)
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			return nil
+		}
+	}
+
+	ctx := &Context{Parallel: "true"}
+	ctx.DefineTask(testTask("generate", run("generate")))
+	ctx.DefineTask(testTask("lint", run("lint"), "generate"))
+	ctx.DefineTask(testTask("test", run("test"), "generate"))
+	ctx.DefineTask(testTask("build", run("build"), "lint", "test"))
+	Expect(ctx.RunTasks("build")).To(BeNil())
+	Expect(started).To(HaveLen(4))
+	Expect(started[0]).To(Equal("generate"))
+	Expect(started[1:3]).To(ConsistOf("lint", "test"))
+	Expect(started[3]).To(Equal("build"))
+	Expect(maxRunning).To(Equal(2))
+
+	started, maxRunning = nil, 0
+	ctx.Parallel = "false"
+	Expect(ctx.RunTasks()).To(BeNil())
+	Expect(started).To(Equal([]string{"generate", "lint", "test", "build"}))
+	Expect(maxRunning).To(Equal(1))
+}
+
+func TestRunTasksFailure(t *testing.T) {
+	RegisterTestingT(t)
+
+	var mutex sync.Mutex
+	var ran []string
+	run := func(name string, err error) func() error {
+		return func() error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			ran = append(ran, name)
+			return err
+		}
+	}
+
+	ctx := &Context{Parallel: "false"}
+	ctx.DefineTask(testTask("test", run("test", fmt.Errorf("tests failed"))))
+	ctx.DefineTask(testTask("build", run("build", nil), "test"))
+	ctx.DefineTask(testTask("lint", run("lint", nil)))
+	err := ctx.RunTasks()
+	Expect(err).To(MatchError("tests failed"))
+	Expect(ran).To(Equal([]string{"test"}))
+
+	ran = nil
+	ctx.KeepGoing = "true"
+	err = ctx.RunTasks()
+	Expect(err).To(MatchError(ContainSubstring("tests failed")))
+	Expect(err).To(MatchError(ContainSubstring("dependency test has failed")))
+	Expect(ran).To(Equal([]string{"test", "lint"}))
+
+	ctx.DefineTask(testTask("release", nil, "deploy"))
+	Expect(ctx.RunTasks()).To(MatchError("task release depends on unknown task deploy"))
+}
+
+func TestRunConfigTasks(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "graph")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	Expect(ioutil.WriteFile(path.Join(tmpDir, "build.yaml"), []byte("outDir: bin\n"), 0644)).To(BeNil())
+	Expect(os.MkdirAll(path.Join(tmpDir, "templates"), os.ModePerm)).To(BeNil())
+	Expect(ioutil.WriteFile(path.Join(tmpDir, "templates", "a.tpl"), []byte("a"), 0644)).To(BeNil())
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(tmpDir)).To(BeNil())
+
+	ctx := &Context{OutDir: "bin", Parallel: "true", Tasks: []TaskConfig{
+		{Name: "generate", Command: "cat templates/*.tpl > generated.go; echo run >> runs.log",
+			Inputs: []string{"templates/*.tpl"}, Outputs: []string{"generated.go"}},
+		{Name: "build", Deps: []string{"generate"}},
+	}}
+	runs := func() string {
+		data, err := ioutil.ReadFile(path.Join(tmpDir, "runs.log"))
+		Expect(err).To(BeNil())
+		return string(data)
+	}
+	Expect(ctx.RunTasks("build")).To(BeNil())
+	Expect(runs()).To(Equal("run\n"))
+	upToDate, err := ctx.IsTaskUpToDate(ctx.Tasks[0])
+	Expect(err).To(BeNil())
+	Expect(upToDate).To(BeTrue())
+	Expect(ctx.RunTasks("build")).To(BeNil())
+	Expect(runs()).To(Equal("run\n"))
+
+	future := time.Now().Add(time.Hour)
+	Expect(os.Chtimes(path.Join(tmpDir, "templates", "a.tpl"), future, future)).To(BeNil())
+	Expect(ctx.RunTasks("build")).To(BeNil())
+	Expect(runs()).To(Equal("run\nrun\n"))
+
+	ctx.Tasks[0].Command = "exit 3"
+	Expect(os.Remove(path.Join(tmpDir, "generated.go"))).To(BeNil())
+	Expect(ctx.RunTasks("build")).To(MatchError(ContainSubstring("exit status 3")))
+}
+
+func TestConfigTasksExtendDefinedTasks(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "graph")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	Expect(ioutil.WriteFile(path.Join(tmpDir, "build.yaml"), []byte("outDir: bin\n"), 0644)).To(BeNil())
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(tmpDir)).To(BeNil())
+
+	var ran []string
+	ctx := &Context{OutDir: "bin", Parallel: "false", Tasks: []TaskConfig{
+		{Name: "build", Deps: []string{"lint", "generate"}, Inputs: []string{"cmd"}},
+		{Name: "lint", Command: "echo lint > lint.log"},
+		{Name: "generate", Description: "Generate from config", Command: "echo generate > generate.log"},
+	}}
+	ctx.DefineTask(testTask("generate", func() error { ran = append(ran, "generate"); return nil }))
+	ctx.DefineTask(testTask("build", func() error { ran = append(ran, "build"); return nil }, "generate"))
+
+	graph, err := ctx.TaskGraph()
+	Expect(err).To(BeNil())
+	build, ok := graph.Task("build")
+	Expect(ok).To(BeTrue())
+	Expect(build.Deps).To(Equal([]string{"generate", "lint"}))
+	Expect(build.Inputs).To(Equal([]string{"cmd"}))
+	generate, _ := graph.Task("generate")
+	Expect(generate.Description).To(Equal("Generate from config"))
+
+	Expect(ctx.RunTasks("build")).To(BeNil())
+	Expect(ran).To(Equal([]string{"build"}))
+	for _, file := range []string{"lint.log", "generate.log"} {
+		_, err := os.Stat(path.Join(tmpDir, file))
+		Expect(err).To(BeNil())
+	}
+
+	ctx.Tasks = append(ctx.Tasks, TaskConfig{Name: "build"})
+	_, err = ctx.TaskGraph()
+	Expect(err).To(MatchError("duplicate task name: build"))
+}
@@ -0,0 +1,91 @@
+package build
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// MinisignFileExt is the extension of signature files
+	MinisignFileExt = ".minisig"
+
+	minisignAlgorithm       = "Ed"
+	minisignHashedAlgorithm = "ED"
+	untrustedCommentPrefix  = "untrusted comment: "
+	trustedCommentPrefix    = "trusted comment: "
+)
+
+// MinisignKeyID returns id of the key which is written to signatures (derived from the public key)
+func MinisignKeyID(publicKey ed25519.PublicKey) []byte {
+	sum := sha256.Sum256(publicKey)
+	return sum[:8]
+}
+
+// WriteMinisignPublicKey writes public key in format accepted by minisign -p
+func WriteMinisignPublicKey(w io.Writer, publicKey ed25519.PublicKey) error {
+	keyID := MinisignKeyID(publicKey)
+	data := append(append([]byte(minisignAlgorithm), keyID...), publicKey...)
+	_, err := fmt.Fprintf(w, "%sminisign public key %016X\n%s\n", untrustedCommentPrefix,
+		binary.LittleEndian.Uint64(keyID), base64.StdEncoding.EncodeToString(data))
+	return err
+}
+
+// WriteMinisign writes prehashed signature of the data in format accepted by minisign -V,
+// the trusted comment records the timestamp and the name of the signed file
+func WriteMinisign(w io.Writer, data []byte, fileName string, timestamp time.Time, privateKey ed25519.PrivateKey) error {
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	hash := blake2b.Sum512(data)
+	signature := ed25519.Sign(privateKey, hash[:])
+	trustedComment := fmt.Sprintf("timestamp:%d\tfile:%s\thashed", timestamp.Unix(), fileName)
+	globalSignature := ed25519.Sign(privateKey, append(append([]byte{}, signature...), trustedComment...))
+	sigData := append(append([]byte(minisignHashedAlgorithm), MinisignKeyID(publicKey)...), signature...)
+	_, err := fmt.Fprintf(w, "%ssignature from build secret key\n%s\n%s%s\n%s\n",
+		untrustedCommentPrefix, base64.StdEncoding.EncodeToString(sigData),
+		trustedCommentPrefix, trustedComment, base64.StdEncoding.EncodeToString(globalSignature))
+	return err
+}
+
+// VerifyMinisign verifies minisign signature (both prehashed and legacy) of the data along with its trusted comment
+func VerifyMinisign(data []byte, signatureFile []byte, publicKey ed25519.PublicKey) error {
+	lines := strings.Split(strings.TrimSpace(string(signatureFile)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return errors.New("malformed minisign signature")
+	}
+	sigData, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sigData) != 2+8+ed25519.SignatureSize {
+		return errors.New("malformed minisign signature")
+	}
+	globalSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return errors.Wrap(err, "malformed minisign trusted comment signature")
+	}
+	algorithm, keyID, signature := string(sigData[:2]), sigData[2:10], sigData[10:]
+	if !bytes.Equal(keyID, MinisignKeyID(publicKey)) {
+		return errors.Errorf("signature was made with another key %016X", binary.LittleEndian.Uint64(keyID))
+	}
+	message := data
+	if algorithm == minisignHashedAlgorithm {
+		hash := blake2b.Sum512(data)
+		message = hash[:]
+	} else if algorithm != minisignAlgorithm {
+		return errors.Errorf("unsupported signature algorithm %q", algorithm)
+	}
+	if !ed25519.Verify(publicKey, message, signature) {
+		return errors.New("signature is invalid")
+	}
+	trustedComment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), trustedCommentPrefix)
+	if !ed25519.Verify(publicKey, append(append([]byte{}, signature...), trustedComment...), globalSignature) {
+		return errors.New("trusted comment signature is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package build
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Errors is a list of errors of tasks which have failed in keep-going mode
+type Errors []error
+
+// Error returns errors one per line
+func (errs Errors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// IsCancelled returns true if error was caused by cancellation of the task
+func IsCancelled(err error) bool {
+	if exitErr, ok := errors.Cause(err).(*ExitError); ok {
+		err = exitErr.Err
+	}
+	cause := errors.Cause(err)
+	return cause == context.Canceled || cause == context.DeadlineExceeded
+}
+
+// runPool runs action for every item with at most jobs actions at a time,
+// items not started by the time ctx is done fail with the context error
+func runPool(ctx context.Context, jobs int, count int, action func(i int) error) []error {
+	if jobs < 1 {
+		jobs = 1
+	}
+	errs := make([]error, count)
+	slots := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		select {
+		case slots <- struct{}{}:
+			if ctx.Err() != nil {
+				<-slots
+				errs[i] = ctx.Err()
+				continue
+			}
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			errs[i] = action(i)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+// joinErrors returns nil if there are no errors, all errors in keep-going mode
+// and the first error which is not a cancellation otherwise
+func joinErrors(errs []error, keepGoing bool) error {
+	var res Errors
+	for _, err := range errs {
+		if err != nil {
+			res = append(res, err)
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	} else if keepGoing {
+		return res
+	}
+	for _, err := range res {
+		if !IsCancelled(err) {
+			return err
+		}
+	}
+	return res[0]
+}
@@ -0,0 +1,82 @@
+package build_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+)
+
+func testTargets(names ...string) []Target {
+	res := make([]Target, len(names))
+	for i, name := range names {
+		res[i] = Target{Name: name}
+	}
+	return res
+}
+
+func TestForAllTargetsLimitsJobs(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := &Context{Parallel: "true", Jobs: 2, FilterTargets: "-", Targets: testTargets("a", "b", "c", "d", "e")}
+	Expect(ctx.MaxJobs()).To(Equal(2))
+
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	err := ctx.ForAllTargets(func(target Target) error {
+		return ctx.RunTask(target.Name, func(out *TaskOutput) error {
+			mutex.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			return nil
+		})
+	})
+	Expect(err).To(BeNil())
+	Expect(maxRunning).To(Equal(2))
+}
+
+func TestForAllTargetsFailFast(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := &Context{Parallel: "true", Jobs: 3, FilterTargets: "-", Targets: testTargets("broken", "slow1", "slow2", "slow3")}
+	started := time.Now()
+	err := ctx.ForAllTargets(func(target Target) error {
+		return ctx.RunTask(target.Name, func(out *TaskOutput) error {
+			if target.Name == "broken" {
+				time.Sleep(50 * time.Millisecond)
+				return errors.New("compilation failed")
+			}
+			return ctx.RunCmd(Cmd{Args: []string{"sleep", "5"}, Context: out.Context(), Stdout: out.Stdout(), Stderr: out.Stderr()})
+		})
+	})
+	Expect(err).To(MatchError("compilation failed"))
+	Expect(time.Since(started)).To(BeNumerically("<", 2*time.Second))
+}
+
+func TestForAllTargetsKeepGoing(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := &Context{Parallel: "false", KeepGoing: "true", FilterTargets: "-", Targets: testTargets("a", "b", "c")}
+	Expect(ctx.MaxJobs()).To(Equal(1))
+
+	var built []string
+	err := ctx.ForAllTargets(func(target Target) error {
+		built = append(built, target.Name)
+		if target.Name != "b" {
+			return errors.New(target.Name + " failed")
+		}
+		return nil
+	})
+	Expect(built).To(Equal([]string{"a", "b", "c"}))
+	Expect(err).To(Equal(Errors{errors.New("a failed"), errors.New("c failed")}))
+}
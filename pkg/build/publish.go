@@ -0,0 +1,91 @@
+package build
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/smecsia/go-utils/pkg/publish"
+)
+
+// PublishPath returns path artifacts of the current version are published under
+func (ctx *Context) PublishPath() (string, error) {
+	pathTemplate := ctx.Publish.Path
+	if pathTemplate == "" {
+		pathTemplate = publish.DefaultPath
+	}
+	tpl, err := template.New("publish").Option("missingkey=error").Parse(pathTemplate)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid publish path template %q", pathTemplate)
+	}
+	var res bytes.Buffer
+	if err := tpl.Execute(&res, struct{ Version string }{ctx.Version}); err != nil {
+		return "", errors.Wrap(err, "failed to render publish path")
+	}
+	return path.Clean(res.String()), nil
+}
+
+// PublishArtifacts returns bundles of active targets with their checksum files followed by checksum manifests
+// and their signatures, artifacts are published under the same paths relative to OutDir
+func (ctx *Context) PublishArtifacts() ([]publish.Artifact, error) {
+	var files []string
+	for _, bundle := range ctx.Bundles() {
+		if _, err := os.Stat(bundle.BinaryFile); err != nil {
+			return nil, errors.Wrapf(err, "bundle of %s for %s is not built", bundle.Target.Name, bundle.Platform.String())
+		}
+		files = append(files, bundle.BinaryFile)
+		if _, err := os.Stat(bundle.ChecksumFile); err == nil {
+			files = append(files, bundle.ChecksumFile)
+		}
+	}
+	for _, algorithm := range ctx.ActiveChecksums() {
+		manifest := ctx.ChecksumsFile(algorithm)
+		for _, file := range []string{manifest, manifest + MinisignFileExt} {
+			if _, err := os.Stat(file); err == nil {
+				files = append(files, file)
+			}
+		}
+	}
+	prefix, err := ctx.PublishPath()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]publish.Artifact, len(files))
+	for i, file := range files {
+		rel, err := filepath.Rel(ctx.OutPath(), file)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = publish.Artifact{File: file, Name: path.Join(prefix, filepath.ToSlash(rel))}
+	}
+	return res, nil
+}
+
+// Publisher returns publisher of the repository configured in publish section
+func (ctx *Context) Publisher() (publish.Publisher, error) {
+	return publish.New(ctx.Publish, publish.Credentials{
+		Username:     ctx.PublishUsername,
+		Password:     ctx.PublishPassword,
+		Token:        ctx.PublishToken,
+		AccessKey:    ctx.AWSAccessKey,
+		SecretKey:    ctx.AWSSecretKey,
+		SessionToken: ctx.AWSSessionToken,
+	})
+}
+
+// PublishBundles publishes bundles and checksum manifests to the configured repository
+// skipping artifacts which are already published
+func (ctx *Context) PublishBundles() ([]publish.Result, error) {
+	publisher, err := ctx.Publisher()
+	if err != nil {
+		return nil, err
+	}
+	artifacts, err := ctx.PublishArtifacts()
+	if err != nil {
+		return nil, err
+	}
+	return publish.Publish(publisher, artifacts, ctx.Publish.Options())
+}
@@ -0,0 +1,57 @@
+package build_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/smecsia/go-utils/pkg/publish"
+)
+
+func TestPublishBundles(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "publish")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	Expect(ioutil.WriteFile(path.Join(tmpDir, "build.yaml"), []byte("outDir: bin\n"), 0644)).To(BeNil())
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(tmpDir)).To(BeNil())
+
+	platform := Platform{GOOS: "linux", GOARCH: "amd64"}
+	ctx := &Context{OutDir: "bin", Version: "1.2.0", FilterTargets: "-", FilterPlatforms: "-",
+		Platforms: []Platform{platform}, Targets: []Target{{Name: "app", Path: "cmd/app/main.go"}},
+		Publish: publish.Config{Type: publish.TypeLocal, Path: "releases/{{.Version}}",
+			Local: publish.LocalConfig{Dir: path.Join(tmpDir, "share")}}}
+
+	_, err = ctx.PublishBundles()
+	Expect(err).To(MatchError(ContainSubstring("bundle of app for linux-amd64 is not built")))
+
+	bundle := ctx.Bundle(ctx.Target("app"), platform)
+	Expect(os.MkdirAll(path.Dir(bundle.BinaryFile), os.ModePerm)).To(BeNil())
+	Expect(ioutil.WriteFile(bundle.BinaryFile, []byte("archive"), 0644)).To(BeNil())
+	Expect(ioutil.WriteFile(ctx.ChecksumsFile(ChecksumSHA256), []byte("sums"), 0644)).To(BeNil())
+
+	artifacts, err := ctx.PublishArtifacts()
+	Expect(err).To(BeNil())
+	Expect(artifacts).To(HaveLen(2))
+	Expect(artifacts[0].Name).To(Equal("releases/1.2.0/linux-amd64/app-linux-amd64.tar.gz"))
+	Expect(artifacts[1].Name).To(Equal("releases/1.2.0/SHA256SUMS"))
+
+	results, err := ctx.PublishBundles()
+	Expect(err).To(BeNil())
+	Expect(results).To(HaveLen(2))
+	Expect(results[0].Status).To(Equal(publish.StatusUploaded))
+	content, err := ioutil.ReadFile(path.Join(tmpDir, "share", "releases", "1.2.0", "SHA256SUMS"))
+	Expect(err).To(BeNil())
+	Expect(string(content)).To(Equal("sums"))
+
+	ctx.Publish.Path = "{{.Target}}"
+	_, err = ctx.PublishArtifacts()
+	Expect(err).To(MatchError(ContainSubstring("failed to render publish path")))
+}
@@ -0,0 +1,200 @@
+package build
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smecsia/go-utils/pkg/render"
+)
+
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+	StatusPlanned   = "planned"
+)
+
+var (
+	reportMutex sync.Mutex
+)
+
+// Report describes artifacts built (or planned to be built) by the context
+type Report struct {
+	Version   string           `json:"version,omitempty"`
+	GoVersion string           `json:"goVersion,omitempty"`
+	DryRun    bool             `json:"dryRun,omitempty"`
+	Artifacts []ArtifactReport `json:"artifacts"`
+	Bundles   []BundleReport   `json:"bundles,omitempty"`
+}
+
+// ArtifactReport describes binary of the target built for platform
+type ArtifactReport struct {
+	Target   string `json:"target"`
+	Platform string `json:"platform"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	LdFlags  string `json:"ldflags,omitempty"`
+	Command  string `json:"command,omitempty"`
+	Cached   bool   `json:"cached,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BundleReport describes archive of the target built for platform
+type BundleReport struct {
+	Target       string `json:"target"`
+	Platform     string `json:"platform"`
+	Path         string `json:"path"`
+	Format       string `json:"format"`
+	ChecksumFile string `json:"checksumFile"`
+	Size         int64  `json:"size,omitempty"`
+	SHA256       string `json:"sha256,omitempty"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Report returns report of artifacts and bundles built so far sorted by target and platform
+func (ctx *Context) Report() Report {
+	reportMutex.Lock()
+	defer reportMutex.Unlock()
+	res := Report{Version: ctx.Version, GoVersion: goVersionName()}
+	if ctx.report != nil {
+		res.Artifacts = append(res.Artifacts, ctx.report.Artifacts...)
+		res.Bundles = append(res.Bundles, ctx.report.Bundles...)
+	}
+	res.sort()
+	return res
+}
+
+// Plan returns report of artifacts and bundles which would be built for active targets and platforms
+// without building anything
+func (ctx *Context) Plan() (Report, error) {
+	res := Report{Version: ctx.Version, GoVersion: goVersionName(), DryRun: true}
+	for _, target := range ctx.ActiveTargets() {
+		for _, platform := range ctx.ActiveTargetPlatforms(target) {
+			cmd, settings, _, err := ctx.goBuild(target, platform)
+			if err != nil {
+				return res, err
+			}
+			artifact := ctx.newArtifactReport(target, platform)
+			artifact.Command, artifact.LdFlags, artifact.Status = cmd.String(), settings.LdFlags, StatusPlanned
+			res.Artifacts = append(res.Artifacts, *artifact)
+			bundle := ctx.newBundleReport(ctx.Bundle(target, platform))
+			bundle.Status = StatusPlanned
+			res.Bundles = append(res.Bundles, bundle)
+		}
+	}
+	res.sort()
+	return res, nil
+}
+
+// WriteReport writes report of artifacts built so far in json, yaml or using template
+func (ctx *Context) WriteReport(w io.Writer, format string) error {
+	return render.Write(w, format, ctx.Report())
+}
+
+// SaveReport writes report to BUILD_REPORT file if it is set (yaml for .yaml/.yml files, json otherwise)
+func (ctx *Context) SaveReport() error {
+	if ctx.ReportFile == "" {
+		return nil
+	}
+	format := render.FormatJSON
+	if ext := filepath.Ext(ctx.ReportFile); ext == ".yaml" || ext == ".yml" {
+		format = render.FormatYAML
+	}
+	f, err := os.Create(ctx.ReportFile)
+	if err != nil {
+		return err
+	}
+	if err := ctx.WriteReport(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (ctx *Context) newArtifactReport(target Target, platform Platform) *ArtifactReport {
+	return &ArtifactReport{Target: target.Name, Platform: platform.String(), Path: ctx.OutFile(target, platform)}
+}
+
+func (ctx *Context) newBundleReport(bundle Bundle) BundleReport {
+	return BundleReport{Target: bundle.Target.Name, Platform: bundle.Platform.String(), Path: bundle.BinaryFile,
+		Format: bundle.Format, ChecksumFile: bundle.ChecksumFile}
+}
+
+// recordArtifact adds finished build to the report
+func (ctx *Context) recordArtifact(artifact *ArtifactReport, duration time.Duration, err error) {
+	artifact.Duration = duration.Round(time.Millisecond).String()
+	artifact.Status, artifact.Error = reportStatus(err)
+	if err == nil {
+		artifact.Size, artifact.SHA256 = fileSizeAndChecksum(artifact.Path)
+	}
+	reportMutex.Lock()
+	defer reportMutex.Unlock()
+	ctx.reportState().Artifacts = append(ctx.report.Artifacts, *artifact)
+}
+
+// recordBundle adds finished bundle to the report
+func (ctx *Context) recordBundle(bundle Bundle, err error) {
+	res := ctx.newBundleReport(bundle)
+	res.Status, res.Error = reportStatus(err)
+	if err == nil {
+		res.Size, res.SHA256 = fileSizeAndChecksum(bundle.BinaryFile)
+	}
+	reportMutex.Lock()
+	defer reportMutex.Unlock()
+	ctx.reportState().Bundles = append(ctx.report.Bundles, res)
+}
+
+func (ctx *Context) reportState() *Report {
+	if ctx.report == nil {
+		ctx.report = &Report{}
+	}
+	return ctx.report
+}
+
+func (r *Report) sort() {
+	sort.SliceStable(r.Artifacts, func(i, j int) bool {
+		return r.Artifacts[i].Target+"/"+r.Artifacts[i].Platform < r.Artifacts[j].Target+"/"+r.Artifacts[j].Platform
+	})
+	sort.SliceStable(r.Bundles, func(i, j int) bool {
+		return r.Bundles[i].Target+"/"+r.Bundles[i].Platform < r.Bundles[j].Target+"/"+r.Bundles[j].Platform
+	})
+}
+
+func reportStatus(err error) (string, string) {
+	if err == nil {
+		return StatusSucceeded, ""
+	} else if IsCancelled(err) {
+		return StatusCancelled, err.Error()
+	}
+	return StatusFailed, err.Error()
+}
+
+func fileSizeAndChecksum(file string) (int64, string) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, ""
+	}
+	sum, err := fileChecksum(ChecksumSHA256, file)
+	if err != nil {
+		return info.Size(), ""
+	}
+	return info.Size(), sum
+}
+
+// goVersionName returns version of go used for builds (e.g. go1.12)
+func goVersionName() string {
+	version, err := currentGoVersion()
+	if fields := strings.Fields(version); err == nil && len(fields) > 2 {
+		return fields[2]
+	}
+	return ""
+}
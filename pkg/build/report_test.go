@@ -0,0 +1,85 @@
+package build_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/smecsia/go-utils/pkg/git/mock"
+)
+
+func TestBuildReport(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "report")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	files := map[string]string{
+		"build.yaml":      "outDir: bin\n",
+		"go.mod":          "module example.com/app\n\ngo 1.12\n",
+		"cmd/app/main.go": "package main\n\nfunc main() {}\n",
+		"cmd/bad/main.go": "package main\n\nfunc main() { undefined() }\n",
+	}
+	for name, content := range files {
+		Expect(os.MkdirAll(path.Dir(path.Join(tmpDir, name)), os.ModePerm)).To(BeNil())
+		Expect(ioutil.WriteFile(path.Join(tmpDir, name), []byte(content), 0644)).To(BeNil())
+	}
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(tmpDir)).To(BeNil())
+
+	gitMock := &mock.GitMock{}
+	gitMock.On("HashShort").Return("abc123d", nil)
+	gitMock.On("Hash").Return("abc123def456", nil)
+	gitMock.On("CurrentBranch").Return("master", nil)
+	gitMock.On("IsWorkTreeClean").Return(true, "", nil)
+	platform := Platform{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}
+	ctx := &Context{OutDir: "bin", Version: "1.0.0", Parallel: "false", NoCache: "true", KeepGoing: "true",
+		FilterTargets: "-", FilterPlatforms: "-", Platforms: []Platform{platform},
+		Targets:    []Target{{Name: "bad", Path: "cmd/bad/main.go"}, {Name: "app", Path: "cmd/app/main.go"}},
+		ReportFile: path.Join(tmpDir, "report.yaml")}
+	ctx.SetGit(gitMock)
+
+	plan, err := ctx.Plan()
+	Expect(err).To(BeNil())
+	Expect(plan.DryRun).To(BeTrue())
+	Expect(plan.Artifacts).To(HaveLen(2))
+	Expect(plan.Artifacts[0].Target).To(Equal("app"))
+	Expect(plan.Artifacts[0].Status).To(Equal(StatusPlanned))
+	Expect(plan.Artifacts[0].Command).To(ContainSubstring("go build -ldflags \"-X main.Version=1.0.0-abc123d\" -o "))
+	Expect(plan.Bundles).To(HaveLen(2))
+	_, err = os.Stat(plan.Artifacts[0].Path)
+	Expect(os.IsNotExist(err)).To(BeTrue())
+
+	Expect(ctx.ForAllTargets(func(target Target) error {
+		return ctx.BuildAllPlatforms(target)
+	})).NotTo(BeNil())
+	Expect(ctx.BuildBundle(ctx.Bundle(ctx.Target("app"), platform))).To(BeNil())
+
+	report := ctx.Report()
+	Expect(report.GoVersion).To(Equal(runtime.Version()))
+	Expect(report.Artifacts).To(HaveLen(2))
+	app, bad := report.Artifacts[0], report.Artifacts[1]
+	Expect(app.Status).To(Equal(StatusSucceeded))
+	Expect(app.Size).To(BeNumerically(">", 0))
+	Expect(app.SHA256).To(HaveLen(64))
+	Expect(app.LdFlags).To(Equal("-X main.Version=1.0.0-abc123d"))
+	Expect(bad.Status).To(Equal(StatusFailed))
+	Expect(bad.Error).To(ContainSubstring("undefined"))
+	Expect(report.Bundles).To(HaveLen(1))
+	Expect(report.Bundles[0].SHA256).To(HaveLen(64))
+
+	var out bytes.Buffer
+	Expect(ctx.WriteReport(&out, "json")).To(BeNil())
+	Expect(out.String()).To(ContainSubstring(`"status": "failed"`))
+	Expect(ctx.SaveReport()).To(BeNil())
+	saved, err := ioutil.ReadFile(ctx.ReportFile)
+	Expect(err).To(BeNil())
+	Expect(string(saved)).To(ContainSubstring("status: succeeded"))
+}
@@ -0,0 +1,114 @@
+package build
+
+import (
+	"sort"
+	"strings"
+)
+
+// BuildSettings are settings of go build for the target merged with global defaults
+type BuildSettings struct {
+	LdFlags  string
+	GcFlags  string
+	Tags     []string
+	Cgo      *bool
+	Env      []string
+	TrimPath bool
+}
+
+// TargetSettings returns build settings of the target: ldflags, gcflags, cgo and trimpath of the target
+// replace global ones, tags and env are added to global ones (target's env wins for the same variable)
+func (ctx *Context) TargetSettings(target Target) BuildSettings {
+	res := BuildSettings{
+		LdFlags: ctx.LdFlags,
+		GcFlags: ctx.GcFlags,
+		Tags:    mergeTags(ctx.Tags, target.Tags),
+		Cgo:     ctx.Cgo,
+		Env:     mergeEnv(ctx.Env, target.Env),
+	}
+	if target.LdFlags != "" {
+		res.LdFlags = target.LdFlags
+	}
+	if target.GcFlags != "" {
+		res.GcFlags = target.GcFlags
+	}
+	if target.Cgo != nil {
+		res.Cgo = target.Cgo
+	}
+	if target.TrimPath != nil {
+		res.TrimPath = *target.TrimPath
+	} else if ctx.TrimPath != nil {
+		res.TrimPath = *ctx.TrimPath
+	}
+	return res
+}
+
+// TargetPlatforms returns platforms to build the target for (global platforms unless target defines its own)
+func (ctx *Context) TargetPlatforms(target Target) []Platform {
+	if len(target.Platforms) > 0 {
+		return target.Platforms
+	}
+	return ctx.Platforms
+}
+
+// ActiveTargetPlatforms returns platforms of the target filtered by PLATFORMS
+func (ctx *Context) ActiveTargetPlatforms(target Target) []Platform {
+	return ctx.filterPlatforms(ctx.TargetPlatforms(target))
+}
+
+// GoFlags returns flags of go build
+func (s BuildSettings) GoFlags() []string {
+	var res []string
+	if s.TrimPath {
+		res = append(res, "-trimpath")
+	}
+	if len(s.Tags) > 0 {
+		res = append(res, "-tags", strings.Join(s.Tags, " "))
+	}
+	if s.GcFlags != "" {
+		res = append(res, "-gcflags", s.GcFlags)
+	}
+	if s.LdFlags != "" {
+		res = append(res, "-ldflags", s.LdFlags)
+	}
+	return res
+}
+
+// GoEnv returns environment variables of go build for the platform
+func (s BuildSettings) GoEnv(platform Platform) []string {
+	res := platform.GoEnv()
+	if s.Cgo != nil {
+		if *s.Cgo {
+			res = append(res, "CGO_ENABLED=1")
+		} else {
+			res = append(res, "CGO_ENABLED=0")
+		}
+	}
+	return append(res, s.Env...)
+}
+
+// mergeTags returns unique tags of both lists keeping their order
+func mergeTags(tags []string, more []string) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, tags...), more...) {
+		if tag = strings.TrimSpace(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			res = append(res, tag)
+		}
+	}
+	return res
+}
+
+// mergeEnv returns variables of both lists, variables of the second list replace ones with the same name
+func mergeEnv(env []string, more []string) []string {
+	values := make(map[string]string)
+	for _, value := range append(append([]string{}, env...), more...) {
+		values[strings.SplitN(value, "=", 2)[0]] = value
+	}
+	res := make([]string, 0, len(values))
+	for _, value := range values {
+		res = append(res, value)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,71 @@
+package build_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"gopkg.in/yaml.v2"
+)
+
+func TestTargetSettings(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := &Context{}
+	err := yaml.Unmarshal([]byte(`
+platforms:
+  - os: linux
+    arch: amd64
+  - os: darwin
+    arch: amd64
+ldflags: -s -w
+tags: [netgo]
+cgo: false
+env: [GOPROXY=off, GOFLAGS=-mod=vendor]
+targets:
+  - name: cli
+    path: cmd/cli/main.go
+  - name: daemon
+    path: cmd/daemon/main.go
+    output: daemond
+    platforms:
+      - os: linux
+        arch: arm64
+    ldflags: -s
+    tags: [daemon, netgo]
+    cgo: true
+    trimpath: true
+    env: [GOFLAGS=-mod=mod, CC=musl-gcc]
+`), ctx)
+	Expect(err).To(BeNil())
+	cli, daemon := ctx.Targets[0], ctx.Targets[1]
+	linux := Platform{GOOS: "linux", GOARCH: "amd64"}
+
+	Expect(ctx.TargetPlatforms(cli)).To(Equal(ctx.Platforms))
+	Expect(ctx.TargetPlatforms(daemon)).To(Equal([]Platform{{GOOS: "linux", GOARCH: "arm64"}}))
+
+	settings := ctx.TargetSettings(cli)
+	Expect(settings.GoFlags()).To(Equal([]string{"-tags", "netgo", "-ldflags", "-s -w"}))
+	Expect(settings.GoEnv(linux)).To(ContainElement("CGO_ENABLED=0"))
+	Expect(settings.GoEnv(linux)).To(ContainElement("GOFLAGS=-mod=vendor"))
+
+	settings = ctx.TargetSettings(daemon)
+	Expect(settings.GoFlags()).To(Equal([]string{"-trimpath", "-tags", "netgo daemon", "-ldflags", "-s"}))
+	Expect(settings.GoEnv(linux)).To(ContainElement("CGO_ENABLED=1"))
+	Expect(settings.Env).To(Equal([]string{"CC=musl-gcc", "GOFLAGS=-mod=mod", "GOPROXY=off"}))
+
+	Expect(ctx.OutFileName(daemon, Platform{GOOS: "windows", GOARCH: "amd64"})).To(Equal("daemond.exe"))
+	Expect(ctx.OutFileName(cli, linux)).To(Equal("cli"))
+
+	ctx.FilterPlatforms = "linux:amd64"
+	Expect(ctx.ActiveTargetPlatforms(cli)).To(Equal([]Platform{linux}))
+	Expect(ctx.ActiveTargetPlatforms(daemon)).To(BeEmpty())
+}
+
+func TestTargetValidate(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect((&Target{Name: "app", Path: "main.go", Env: []string{"FOO"}}).Validate()).NotTo(BeNil())
+	Expect((&Target{Name: "app", Path: "main.go", Output: "bin/app"}).Validate()).NotTo(BeNil())
+	Expect((&Target{Name: "app", Path: "main.go", Output: "appd", Env: []string{"FOO=bar"}}).Validate()).To(BeNil())
+}
@@ -0,0 +1,99 @@
+package build
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+)
+
+const (
+	VersionSourceConfig = "config"
+	VersionSourceGit    = "git"
+
+	// defaultGitVersion is used when version is taken from git and there are no version tags yet
+	defaultGitVersion = "0.0.0"
+)
+
+// VersionDescription describes HEAD relative to the nearest version tag (like git describe --tags)
+type VersionDescription struct {
+	Tag      string
+	Version  string
+	Distance int
+	Hash     string
+	Dirty    bool
+}
+
+// String returns description in git describe format (v1.2.0-5-gabc1234-dirty)
+func (d VersionDescription) String() string {
+	res := d.Tag
+	if res == "" {
+		res = d.Hash
+	} else if d.Distance > 0 {
+		res = fmt.Sprintf("%s-%d-g%s", res, d.Distance, d.Hash)
+	}
+	if d.Dirty {
+		res += "-dirty"
+	}
+	return res
+}
+
+// IsGitVersion returns true if version is taken from the nearest version tag instead of the config file
+func (ctx *Context) IsGitVersion() bool {
+	return ctx.VersionSource == VersionSourceGit
+}
+
+// DescribeVersion finds the nearest version tag (annotated or lightweight) reachable from HEAD,
+// version of the config (0.0.0 if it is not set) is used if there are no version tags
+func (ctx *Context) DescribeVersion() (VersionDescription, error) {
+	commits, tag, err := ctx.commitsSince("", "")
+	if err != nil {
+		return VersionDescription{}, err
+	}
+	res := VersionDescription{Tag: tag.name, Distance: len(commits)}
+	if tag.version != nil {
+		res.Version = tag.version.String()
+	} else if res.Version = ctx.Version; res.Version == "" {
+		res.Version = defaultGitVersion
+	}
+	if res.Hash, err = ctx.gitClient().HashShort(); err != nil {
+		return res, errors.Wrap(err, "unable to detect Git hash")
+	}
+	clean, _, err := ctx.gitClient().IsWorkTreeClean()
+	if err != nil {
+		return res, errors.Wrap(err, "unable to check Git work tree")
+	}
+	res.Dirty = !clean
+	return res, nil
+}
+
+// VersionTag returns name of the tag of version
+func (ctx *Context) VersionTag(version string) string {
+	return ctx.TagPrefix + version
+}
+
+// TagVersion creates tag of the version pointing to HEAD and pushes it to the remote
+func (ctx *Context) TagVersion(version string, message string) (string, error) {
+	if _, err := semver.NewVersion(version); err != nil {
+		return "", errors.Wrapf(err, "invalid version %q", version)
+	}
+	tag := ctx.VersionTag(version)
+	if err := ctx.gitClient().CreateTag(tag, message); err != nil {
+		return tag, err
+	}
+	return tag, ctx.gitClient().PushTag(tag)
+}
+
+// SaveVersion makes version current: it is written to the config file or, if version is taken from git,
+// the version tag is created and pushed
+func (ctx *Context) SaveVersion(version string) error {
+	if ctx.IsGitVersion() {
+		if _, err := ctx.TagVersion(version, fmt.Sprintf("Version %s", version)); err != nil {
+			return err
+		}
+	} else if err := ctx.SetVersionInConfig(version); err != nil {
+		return err
+	}
+	ctx.Version = version
+	return nil
+}
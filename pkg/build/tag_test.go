@@ -0,0 +1,118 @@
+package build_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/smecsia/go-utils/pkg/git"
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func TestVersionFromGitTags(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "tag")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	remoteDir, workDir := path.Join(tmpDir, "remote.git"), path.Join(tmpDir, "work")
+	remote, err := gogit.PlainInit(remoteDir, true)
+	Expect(err).To(BeNil())
+	Expect(os.MkdirAll(workDir, os.ModePerm)).To(BeNil())
+	repo := newTestRepo(workDir)
+	_, err = repo.repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+	Expect(err).To(BeNil())
+	ctx := &Context{VersionSource: VersionSourceGit, TagPrefix: "v", AuditLog: "-"}
+	ctx.SetGit(git.NewWithCfg(workDir, "dev", "master", "origin"))
+
+	first := repo.commit("feat: initial version")
+	Expect(ctx.Init()).To(BeNil())
+	Expect(ctx.Version).To(Equal("0.0.0"))
+
+	repo.tag("v0.9.0", first, false)
+	repo.tag("v1.0.0", first, true)
+	Expect(ctx.Init()).To(BeNil())
+	Expect(ctx.Version).To(Equal("1.0.0"))
+	description, err := ctx.DescribeVersion()
+	Expect(err).To(BeNil())
+	Expect(description.String()).To(Equal("v1.0.0"))
+
+	head := repo.commit("fix: handle empty config")
+	description, err = ctx.DescribeVersion()
+	Expect(err).To(BeNil())
+	Expect(description.Tag).To(Equal("v1.0.0"))
+	Expect(description.Distance).To(Equal(1))
+	Expect(description.String()).To(Equal("v1.0.0-1-g" + head.String()[:7]))
+	Expect(ioutil.WriteFile(path.Join(workDir, "CHANGES"), []byte("uncommitted"), 0644)).To(BeNil())
+	description, err = ctx.DescribeVersion()
+	Expect(err).To(BeNil())
+	Expect(description.Dirty).To(BeTrue())
+	Expect(description.String()).To(HaveSuffix("-dirty"))
+
+	bump, err := ctx.BumpVersion()
+	Expect(err).To(BeNil())
+	Expect(bump.Version).To(Equal("1.0.1"))
+	Expect(ctx.Version).To(Equal("1.0.1"))
+	ref, err := remote.Tag("v1.0.1")
+	Expect(err).To(BeNil())
+	tag, err := remote.TagObject(ref.Hash())
+	Expect(err).To(BeNil())
+	Expect(tag.Target).To(Equal(head))
+	Expect(tag.Message).To(Equal("Version 1.0.1\n"))
+
+	_, err = ctx.TagVersion("1.0.1", "")
+	Expect(err).To(MatchError(ContainSubstring("unable to create tag v1.0.1")))
+	_, err = ctx.TagVersion("latest", "")
+	Expect(err).To(MatchError(ContainSubstring("invalid version")))
+	_, err = ctx.TagVersion("1.0.2", "")
+	Expect(err).To(BeNil())
+	ref, err = remote.Tag("v1.0.2")
+	Expect(err).To(BeNil())
+	Expect(ref.Hash()).To(Equal(head))
+	Expect(ref.Name()).To(Equal(plumbing.NewTagReferenceName("v1.0.2")))
+
+	ctx.VersionSource = "tags"
+	Expect(ctx.Validate()).To(MatchError(ContainSubstring("unsupported version source")))
+}
+
+func TestVersionFromPrefixedGitTags(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "tag")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	remoteDir, workDir := path.Join(tmpDir, "remote.git"), path.Join(tmpDir, "work")
+	_, err = gogit.PlainInit(remoteDir, true)
+	Expect(err).To(BeNil())
+	Expect(os.MkdirAll(workDir, os.ModePerm)).To(BeNil())
+	repo := newTestRepo(workDir)
+	_, err = repo.repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+	Expect(err).To(BeNil())
+	ctx := &Context{VersionSource: VersionSourceGit, TagPrefix: "release-", AuditLog: "-"}
+	ctx.SetGit(git.NewWithCfg(workDir, "dev", "master", "origin"))
+
+	first := repo.commit("feat: initial version")
+	repo.tag("release-1.0.0", first, true)
+	repo.tag("v2.0.0", first, false)
+	repo.commit("fix: handle empty config")
+	description, err := ctx.DescribeVersion()
+	Expect(err).To(BeNil())
+	Expect(description.Tag).To(Equal("release-1.0.0"))
+	Expect(description.Version).To(Equal("1.0.0"))
+	Expect(description.Distance).To(Equal(1))
+
+	bump, err := ctx.BumpVersion()
+	Expect(err).To(BeNil())
+	Expect(bump.Version).To(Equal("1.0.1"))
+	description, err = ctx.DescribeVersion()
+	Expect(err).To(BeNil())
+	Expect(description.String()).To(Equal("release-1.0.1"))
+	bump, err = ctx.BumpVersion()
+	Expect(err).To(BeNil())
+	Expect(bump.Version).To(Equal("1.0.1"))
+}
@@ -0,0 +1,262 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/smecsia/go-utils/pkg/util"
+)
+
+const (
+	taskTailSize     = 16384
+	taskSummaryLines = 10
+	taskLogsDir      = "logs"
+)
+
+var (
+	tasksMutex sync.Mutex
+)
+
+// TaskOutput captures output of the single build task prefixing it with the task name
+type TaskOutput struct {
+	Name   string
+	Logger util.Logger
+	ctx    context.Context
+	stdout io.Writer
+	stderr io.Writer
+	flush  []*util.PrefixWriter
+	tail   *tailWriter
+	file   *os.File
+}
+
+// TaskFailure describes failed task along with its last output lines
+type TaskFailure struct {
+	Name   string
+	Err    error
+	Output []string
+}
+
+// taskState keeps track of running tasks and their failures
+type taskState struct {
+	mutex     sync.Mutex
+	depth     int
+	total     int
+	cancelled int
+	failures  []TaskFailure
+	// ctx is cancelled on the first failure, slots limit number of tasks running at a time
+	ctx    context.Context
+	cancel context.CancelFunc
+	slots  chan struct{}
+}
+
+// TaskName returns name of the task building target for platform (e.g. app/linux-amd64)
+func TaskName(target Target, platform Platform) string {
+	return fmt.Sprintf("%s/%s-%s", target.Name, platform.GOOS, platform.GOARCH)
+}
+
+// RunTask runs action providing it with output of its own, failures are collected for the summary.
+// Within ForAllTargets/ForAllPlatforms task waits for a free slot (see MaxJobs), so it must not run nested tasks
+func (ctx *Context) RunTask(name string, action func(out *TaskOutput) error) error {
+	state := ctx.taskState()
+	runCtx, release, err := state.acquire()
+	if err != nil {
+		state.finished(name, err, nil)
+		return err
+	}
+	defer release()
+	out, err := ctx.newTaskOutput(runCtx, name)
+	if err != nil {
+		return err
+	}
+	err = action(out)
+	out.Close()
+	state.finished(name, err, out.lastLines(taskSummaryLines))
+	return err
+}
+
+// TaskFailures returns failures of the tasks which have finished so far
+func (ctx *Context) TaskFailures() []TaskFailure {
+	state := ctx.taskState()
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	return append([]TaskFailure{}, state.failures...)
+}
+
+// WriteTaskSummary writes list of failed tasks along with their last output lines
+func (ctx *Context) WriteTaskSummary(w io.Writer) {
+	state := ctx.taskState()
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	writeTaskSummary(w, state.total, state.cancelled, state.failures)
+}
+
+// Context returns context which is cancelled when sibling task fails
+func (out *TaskOutput) Context() context.Context {
+	return out.ctx
+}
+
+// Stdout returns writer for the standard output of the task
+func (out *TaskOutput) Stdout() io.Writer {
+	return out.stdout
+}
+
+// Stderr returns writer for the error output of the task
+func (out *TaskOutput) Stderr() io.Writer {
+	return out.stderr
+}
+
+// Close flushes incomplete lines and closes log file of the task
+func (out *TaskOutput) Close() {
+	for _, writer := range out.flush {
+		_ = writer.Flush()
+	}
+	if out.file != nil {
+		_ = out.file.Close()
+	}
+}
+
+func (out *TaskOutput) lastLines(count int) []string {
+	tail := out.tail.String()
+	if tail == "" {
+		return nil
+	}
+	lines := strings.Split(tail, "\n")
+	if len(lines) > count {
+		lines = lines[len(lines)-count:]
+	}
+	return lines
+}
+
+func (ctx *Context) newTaskOutput(runCtx context.Context, name string) (*TaskOutput, error) {
+	prefix := "[" + name + "] "
+	out := &TaskOutput{Name: name, ctx: runCtx, tail: &tailWriter{size: taskTailSize}}
+	var file io.Writer = ioutil.Discard
+	if ctx.IsTaskLogs() {
+		logFile := ctx.TaskLogFile(name)
+		if err := os.MkdirAll(filepath.Dir(logFile), os.ModePerm); err != nil {
+			return nil, err
+		}
+		f, err := os.Create(logFile)
+		if err != nil {
+			return nil, err
+		}
+		out.file, file = f, f
+	}
+	stdout, stderr := util.NewPrefixWriter(os.Stdout, prefix), util.NewPrefixWriter(os.Stderr, prefix)
+	out.flush = []*util.PrefixWriter{stdout, stderr}
+	out.stdout = io.MultiWriter(stdout, out.tail, file)
+	out.stderr = io.MultiWriter(stderr, out.tail, file)
+	out.Logger = util.NewWriterLogger(out.stdout, "")
+	return out, nil
+}
+
+// TaskLogFile returns path to the log file of the task (OutDir/logs/<target>-<os>-<arch>.log)
+func (ctx *Context) TaskLogFile(name string) string {
+	return filepath.Join(ctx.OutPath(), taskLogsDir, strings.Replace(name, "/", "-", -1)+".log")
+}
+
+// forAll runs action for every item using bounded pool of workers (see MaxJobs), cancelling
+// the rest of tasks on the first failure unless keep-going mode is on
+func (ctx *Context) forAll(count int, action func(i int) error) error {
+	return ctx.trackTasks(func(state *taskState) error {
+		errs := runPool(state.ctx, ctx.MaxJobs(), count, func(i int) error {
+			err := action(i)
+			if err != nil && !ctx.IsKeepGoing() {
+				state.cancel()
+			}
+			return err
+		})
+		return joinErrors(errs, ctx.IsKeepGoing())
+	})
+}
+
+// trackTasks runs group of tasks printing summary of failed ones once the outermost group is finished
+func (ctx *Context) trackTasks(run func(state *taskState) error) error {
+	state := ctx.taskState()
+	state.mutex.Lock()
+	if state.depth == 0 {
+		state.ctx, state.cancel = context.WithCancel(context.Background())
+		state.slots = make(chan struct{}, ctx.MaxJobs())
+	}
+	state.depth++
+	state.mutex.Unlock()
+
+	err := run(state)
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.depth--
+	if state.depth == 0 {
+		if len(state.failures) > 0 {
+			writeTaskSummary(os.Stdout, state.total, state.cancelled, state.failures)
+		}
+		state.cancel()
+		state.total, state.cancelled, state.failures = 0, 0, nil
+		state.ctx, state.cancel, state.slots = nil, nil, nil
+	}
+	return err
+}
+
+func (ctx *Context) taskState() *taskState {
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+	if ctx.tasks == nil {
+		ctx.tasks = &taskState{}
+	}
+	return ctx.tasks
+}
+
+// acquire waits for a free slot returning context of the task along with the function releasing the slot
+func (state *taskState) acquire() (context.Context, func(), error) {
+	state.mutex.Lock()
+	runCtx, slots := state.ctx, state.slots
+	state.mutex.Unlock()
+	if runCtx == nil {
+		return context.Background(), func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		if runCtx.Err() != nil {
+			<-slots
+			return nil, nil, runCtx.Err()
+		}
+		return runCtx, func() { <-slots }, nil
+	case <-runCtx.Done():
+		return nil, nil, runCtx.Err()
+	}
+}
+
+func (state *taskState) finished(name string, err error, output []string) {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.total++
+	if IsCancelled(err) {
+		state.cancelled++
+	} else if err != nil {
+		state.failures = append(state.failures, TaskFailure{Name: name, Err: err, Output: output})
+	}
+}
+
+func writeTaskSummary(w io.Writer, total int, cancelled int, failures []TaskFailure) {
+	if len(failures) == 0 && cancelled == 0 {
+		fmt.Fprintf(w, "All %d tasks succeeded\n", total)
+		return
+	}
+	fmt.Fprintf(w, "%d of %d tasks failed", len(failures), total)
+	if cancelled > 0 {
+		fmt.Fprintf(w, " (%d cancelled)", cancelled)
+	}
+	fmt.Fprintln(w, ":")
+	for _, failure := range failures {
+		fmt.Fprintf(w, "[%s] %s\n", failure.Name, strings.SplitN(failure.Err.Error(), "\n", 2)[0])
+		for _, line := range failure.Output {
+			fmt.Fprintf(w, "    | %s\n", line)
+		}
+	}
+}
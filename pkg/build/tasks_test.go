@@ -0,0 +1,63 @@
+package build_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+)
+
+func TestRunTaskSummary(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := &Context{}
+	err := ctx.RunTask("app/linux-amd64", func(out *TaskOutput) error {
+		out.Logger.Logf("Building...")
+		return ctx.RunCmd(Cmd{Command: "echo 'main.go:1: syntax error' >&2; exit 2",
+			Stdout: out.Stdout(), Stderr: out.Stderr()})
+	})
+	Expect(err).NotTo(BeNil())
+	Expect(ctx.RunTask("app/darwin-amd64", func(out *TaskOutput) error { return nil })).To(BeNil())
+
+	failures := ctx.TaskFailures()
+	Expect(failures).To(HaveLen(1))
+	Expect(failures[0].Name).To(Equal("app/linux-amd64"))
+	Expect(failures[0].Output).To(Equal([]string{
+		"Building...",
+		`Executing 'bash "-c" "echo 'main.go:1: syntax error' >&2; exit 2"'`,
+		"main.go:1: syntax error",
+	}))
+
+	var buf bytes.Buffer
+	ctx.WriteTaskSummary(&buf)
+	Expect(buf.String()).To(HavePrefix("1 of 2 tasks failed:\n[app/linux-amd64] Failed to execute"))
+	Expect(buf.String()).To(HaveSuffix("    | main.go:1: syntax error\n"))
+}
+
+func TestRunTaskLogs(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "tasks")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	Expect(ioutil.WriteFile(path.Join(tmpDir, "build.yaml"), []byte("outDir: bin\n"), 0644)).To(BeNil())
+	cwd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	defer os.Chdir(cwd)
+	Expect(os.Chdir(tmpDir)).To(BeNil())
+
+	ctx := &Context{OutDir: "bin", TaskLogs: "true"}
+	Expect(ctx.RunTask("app/linux-amd64", func(out *TaskOutput) error {
+		return ctx.RunCmd(Cmd{Args: []string{"echo", "done"}, Stdout: out.Stdout(), Stderr: out.Stderr()})
+	})).To(BeNil())
+
+	logFile := ctx.TaskLogFile("app/linux-amd64")
+	Expect(logFile).To(HaveSuffix("bin/logs/app-linux-amd64.log"))
+	data, err := ioutil.ReadFile(logFile)
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal("Executing 'echo done'\ndone\n"))
+}
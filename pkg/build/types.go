@@ -1,40 +1,100 @@
 package build
 
 import (
+	"context"
 	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"github.com/smecsia/go-utils/pkg/buildinfo"
+	"github.com/smecsia/go-utils/pkg/config"
+	"github.com/smecsia/go-utils/pkg/flags"
 	"github.com/smecsia/go-utils/pkg/git"
+	"github.com/smecsia/go-utils/pkg/publish"
+	"io"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 )
 
 type Generator func() error
 
 // Cmd executable command definition
 type Cmd struct {
+	// Command is executed in the sub shell (bash -c) if Args are not set
 	Command string
-	Wd      string
-	Env     []string
+	// Args is the argv form of command (Args[0] is the executable), arguments are passed as is
+	Args []string
+	Wd   string
+	Env  []string
+	// ReplaceEnv runs command with Env only instead of appending it to the current environment
+	ReplaceEnv bool
+	// Context cancels command when done
+	Context context.Context
+	// Timeout kills command if it runs longer
+	Timeout time.Duration
+	// Stdout and Stderr receive output of the command (os.Stdout and os.Stderr by default)
+	Stdout io.Writer
+	Stderr io.Writer
 }
 
 // Context build context and config
 type Context struct {
-	OutDir       string     `yaml:"outDir,omitempty" env:"OUT_DIR" default:"bin"`
-	Version      string     `yaml:"version,omitempty" env:"VERSION" default:""`
-	Platforms    []Platform `yaml:"platforms,omitempty"`
-	Targets      []Target   `yaml:"targets,omitempty"`
+	OutDir    string      `yaml:"outDir,omitempty" env:"OUT_DIR" default:"bin" description:"Directory for build artifacts relative to project root"`
+	Version   string      `yaml:"version,omitempty" env:"VERSION" default:"" description:"Current version of the project (semver)"`
+	Platforms []Platform  `yaml:"platforms,omitempty" description:"Platforms to build every target for"`
+	Targets   []Target    `yaml:"targets,omitempty" description:"Binaries to build"`
+	Flags     flags.Flags `yaml:"flags,omitempty" description:"Feature flags evaluated by the build"`
+	Jobs      int64       `yaml:"jobs,omitempty" env:"JOBS" default:"0" description:"Maximum number of build tasks running at a time (number of CPUs if 0)"`
+	CacheDir  string      `yaml:"cacheDir,omitempty" env:"BUILD_CACHE_DIR" default:"" optional:"true" description:"Directory of build cache (user cache dir by default), artifacts of other commits are reused only if git hash is not linked into binaries (see versionFormat and buildInfoPackage)"`
+	LdFlags   string      `yaml:"ldflags,omitempty" optional:"true" description:"Linker flags of every target (-X main.Version is always added)" example:"-s -w"`
+	GcFlags   string      `yaml:"gcflags,omitempty" optional:"true" description:"Compiler flags of every target" example:"all=-trimpath=${GOPATH}"`
+	Tags      []string    `yaml:"tags,omitempty" description:"Build tags of every target" example:"netgo"`
+	Cgo       *bool       `yaml:"cgo,omitempty" description:"Enable cgo for every target (inherited from environment if not set)" example:"false"`
+	Env       []string    `yaml:"env,omitempty" description:"Environment variables (KEY=VALUE) of every build" example:"GOPROXY=off"`
+	TrimPath  *bool       `yaml:"trimpath,omitempty" description:"Remove file system paths from every binary" example:"true"`
+
+	VersionSource    string          `yaml:"versionSource,omitempty" env:"VERSION_SOURCE" default:"config" description:"Source of the current version: version key of the config file or the nearest version tag (git describe)" allowed:"config,git"`
+	TagPrefix        string          `yaml:"tagPrefix,omitempty" default:"v" description:"Prefix of version tags created on release (other tags are ignored when reading version)" example:"release-"`
+	VersionFormat    string          `yaml:"versionFormat,omitempty" env:"VERSION_FORMAT" default:"hash" description:"Format of full version: version + git hash, git hash as build metadata, plain version or snapshot version" allowed:"hash,semver,plain,snapshot"`
+	Archive          ArchiveConfig   `yaml:"archive,omitempty" description:"Archives (bundles) of every target"`
+	Changelog        ChangelogConfig `yaml:"changelog,omitempty" description:"Changelog generated from conventional commits"`
+	Tasks            []TaskConfig    `yaml:"tasks,omitempty" description:"Tasks run by the task graph runner along with their dependencies (tasks named as predefined ones extend them)"`
+	Publish          publish.Config  `yaml:"publish,omitempty" description:"Repository bundles and checksum manifests are published to"`
+	Checksums        []string        `yaml:"checksums,omitempty" description:"Checksum manifests covering bundles (sha256 only by default)" example:"sha512" allowed:"sha256,sha512"`
+	BuildInfoPackage string          `yaml:"buildInfoPackage,omitempty" default:"github.com/smecsia/go-utils/pkg/buildinfo" description:"Import path of the package build info is injected into (- disables it)" example:"github.com/acme/app/vendor/github.com/smecsia/go-utils/pkg/buildinfo"`
 
 	// env-only fields
-	GitAuthor       string `yaml:"-" default:"bambooagent" env:"GIT_AUTHOR"`
-	GitBranch       string `yaml:"-" default:"master" env:"GIT_BRANCH"`
-	GitRemote       string `yaml:"-" default:"origin" env:"GIT_REMOTE"`
-	Parallel        string `yaml:"-" default:"true" env:"PARALLEL"`
-	SkipTests       string `yaml:"-" default:"false" env:"SKIP_TESTS"`
-	Verbose         string `yaml:"-" default:"false" env:"VERBOSE"`
-	FilterTargets   string `yaml:"-" default:"-" env:"TARGETS"`
-	FilterPlatforms string `yaml:"-" default:"-" env:"PLATFORMS"`
+	GitAuthor       string `yaml:"-" default:"bambooagent" env:"GIT_AUTHOR" description:"Author of automatically generated commits"`
+	GitBranch       string `yaml:"-" default:"master" env:"GIT_BRANCH" description:"Branch to push automatically generated commits to"`
+	GitRemote       string `yaml:"-" default:"origin" env:"GIT_REMOTE" description:"Remote to push automatically generated commits to"`
+	Parallel        string `yaml:"-" default:"true" env:"PARALLEL" description:"Run builds concurrently" allowed:"true,false"`
+	SkipTests       string `yaml:"-" default:"false" env:"SKIP_TESTS" description:"Skip running tests" allowed:"true,false"`
+	Verbose         string `yaml:"-" default:"false" env:"VERBOSE" description:"Verbose output" allowed:"true,false"`
+	FilterTargets   string `yaml:"-" default:"-" env:"TARGETS" description:"Comma-separated list of targets to build (- for all)"`
+	FilterPlatforms string `yaml:"-" default:"-" env:"PLATFORMS" description:"Comma-separated list of os:arch platforms to build (- for all)"`
+	AuditLog        string `yaml:"-" default:"" env:"AUDIT_LOG" optional:"true" description:"Path to config audit log (next to the config file by default, - disables it)"`
+	TaskLogs        string `yaml:"-" default:"false" env:"TASK_LOGS" description:"Write output of every build task to OutDir/logs" allowed:"true,false"`
+	NoCache         string `yaml:"-" default:"false" env:"NO_CACHE" description:"Always rebuild targets ignoring build cache" allowed:"true,false"`
+	KeepGoing       string `yaml:"-" default:"false" env:"KEEP_GOING" description:"Keep running build tasks after failure and report all errors" allowed:"true,false"`
+	Builder         string `yaml:"-" default:"" env:"BUILDER" optional:"true" description:"Builder recorded in build info (user@host by default)"`
+	SourceDateEpoch string `yaml:"-" default:"" env:"SOURCE_DATE_EPOCH" optional:"true" description:"Unix time used as build date for reproducible builds (current time by default)"`
+	ReportFile      string `yaml:"-" default:"" env:"BUILD_REPORT" optional:"true" description:"File to write build report to (yaml for .yaml files, json otherwise)"`
+	SigningKey      string `yaml:"-" default:"" env:"RELEASE_SIGNING_KEY" optional:"true" description:"Path to ed25519 private key signing checksum manifests (see config:keygen)"`
+	PublishUsername string `yaml:"-" default:"" env:"PUBLISH_USERNAME" optional:"true" description:"Username of the HTTP repository artifacts are published to"`
+	PublishPassword string `yaml:"-" default:"" env:"PUBLISH_PASSWORD" optional:"true" description:"Password (or API key) of the HTTP repository artifacts are published to"`
+	PublishToken    string `yaml:"-" default:"" env:"PUBLISH_TOKEN" optional:"true" description:"Bearer token of the HTTP repository artifacts are published to (instead of username and password)"`
+	AWSAccessKey    string `yaml:"-" default:"" env:"AWS_ACCESS_KEY_ID" optional:"true" description:"Access key of the S3 bucket artifacts are published to"`
+	AWSSecretKey    string `yaml:"-" default:"" env:"AWS_SECRET_ACCESS_KEY" optional:"true" description:"Secret key of the S3 bucket artifacts are published to"`
+	AWSSessionToken string `yaml:"-" default:"" env:"AWS_SESSION_TOKEN" optional:"true" description:"Session token of temporary credentials of the S3 bucket"`
 
 	// init-only private fields
 	configFilePath string
 	git            git.Git
+	tasks          *taskState
+	buildInfo      *buildinfo.Info
+	report         *Report
+	definedTasks   []Task
 }
 
 func (ctx *Context) SetConfigFilePath(path string) {
@@ -45,27 +105,158 @@ func (ctx *Context) GetConfigFilePath() string {
 	return ctx.configFilePath
 }
 
+// Init is called once config is loaded; git client is created lazily on first use
+// unless version is taken from git tags
 func (ctx *Context) Init() error {
-	ctx.git = git.NewWithCfg(ctx.GitRoot(), ctx.GitAuthor, ctx.GitBranch, ctx.GitRemote)
+	if !ctx.IsGitVersion() {
+		return nil
+	}
+	description, err := ctx.DescribeVersion()
+	if err != nil {
+		return errors.Wrap(err, "unable to detect version from git tags")
+	}
+	ctx.Version = description.Version
+	return nil
+}
+
+// Normalize brings config values to canonical form
+func (ctx *Context) Normalize() {
+	ctx.FilterTargets = strings.Replace(ctx.FilterTargets, " ", "", -1)
+	ctx.FilterPlatforms = strings.Replace(ctx.FilterPlatforms, " ", "", -1)
+	ctx.Version = strings.TrimSpace(ctx.Version)
+}
+
+// Validate checks that config is consistent
+func (ctx *Context) Validate() error {
+	names := make(map[string]bool)
+	for _, target := range ctx.Targets {
+		if names[target.Name] {
+			return fmt.Errorf("duplicate target name: %s", target.Name)
+		}
+		names[target.Name] = true
+	}
+	if ctx.Jobs < 0 {
+		return fmt.Errorf("jobs must not be negative: %d", ctx.Jobs)
+	}
+	if err := validateEnv(ctx.Env); err != nil {
+		return err
+	}
+	if ctx.VersionSource != "" && ctx.VersionSource != VersionSourceConfig && ctx.VersionSource != VersionSourceGit {
+		return fmt.Errorf("unsupported version source %q, expected %s or %s", ctx.VersionSource, VersionSourceConfig, VersionSourceGit)
+	}
+	if err := validateVersionFormat(ctx.VersionFormat); err != nil {
+		return err
+	}
+	for _, algorithm := range ctx.Checksums {
+		if algorithm != ChecksumSHA256 && algorithm != ChecksumSHA512 {
+			return fmt.Errorf("unsupported checksum algorithm %q, expected one of %v", algorithm, ChecksumAlgorithms)
+		}
+	}
+	if ctx.Version != "" {
+		if _, err := semver.NewVersion(ctx.Version); err != nil {
+			return errors.Wrapf(err, "invalid version %q", ctx.Version)
+		}
+	}
 	return nil
 }
 
+// Auditor returns auditor recording changes of the config file
+// (audit log is kept next to the config file unless AUDIT_LOG is set, "-" disables it)
+func (ctx *Context) Auditor() *config.Auditor {
+	path := ctx.AuditLog
+	if path == "-" || (path == "" && ctx.GetConfigFilePath() == "") {
+		return nil
+	}
+	if path == "" {
+		configPath := ctx.GetConfigFilePath()
+		path = filepath.Join(filepath.Dir(configPath), filepath.Base(config.AuditFilePath(configPath)))
+	}
+	return config.NewFileAuditor(path, ctx.GitAuthor)
+}
+
+// SetGit sets git client to be used by context
+func (ctx *Context) SetGit(client git.Git) {
+	ctx.git = client
+}
+
+// gitClient returns git client creating it on first use
+func (ctx *Context) gitClient() git.Git {
+	if ctx.git == nil {
+		ctx.git = git.NewWithCfg(ctx.GitRoot(), ctx.GitAuthor, ctx.GitBranch, ctx.GitRemote)
+	}
+	return ctx.git
+}
+
 // Platform defines platform to run build for
 type Platform struct {
-	GOOS   string `yaml:"os,omitempty"`
-	GOARCH string `yaml:"arch,omitempty"`
+	GOOS   string `yaml:"os,omitempty" description:"Target operating system (GOOS)" example:"linux"`
+	GOARCH string `yaml:"arch,omitempty" description:"Target architecture (GOARCH)" example:"amd64"`
 }
 
-// Target defines target to run build for
+// Target defines target to run build for, empty settings are taken from global ones (see TargetSettings)
 type Target struct {
-	Name string `yaml:"name,omitempty"`
-	Path string `yaml:"path,omitempty"`
+	Name      string     `yaml:"name,omitempty" description:"Name of the binary" example:"app"`
+	Path      string     `yaml:"path,omitempty" description:"Path to the main package relative to project root" example:"cmd/app/main.go"`
+	Output    string     `yaml:"output,omitempty" description:"Name of the binary file (target name by default)" example:"appd"`
+	Platforms []Platform `yaml:"platforms,omitempty" description:"Platforms to build the target for (replace global platforms)"`
+	LdFlags   string     `yaml:"ldflags,omitempty" description:"Linker flags (replace global ldflags)" example:"-s -w"`
+	GcFlags   string     `yaml:"gcflags,omitempty" description:"Compiler flags (replace global gcflags)"`
+	Tags      []string   `yaml:"tags,omitempty" description:"Build tags (added to global tags)" example:"daemon"`
+	Cgo       *bool      `yaml:"cgo,omitempty" description:"Enable cgo (replaces global cgo)" example:"true"`
+	Env       []string   `yaml:"env,omitempty" description:"Environment variables KEY=VALUE (added to global env)" example:"CC=musl-gcc"`
+	TrimPath  *bool      `yaml:"trimpath,omitempty" description:"Remove file system paths from the binary (replaces global trimpath)" example:"true"`
+
+	Archive ArchiveConfig `yaml:"archive,omitempty" description:"Archive of the target (format and name replace global ones, files are added to global ones)"`
+}
+
+// String returns name of the target
+func (target Target) String() string {
+	return target.Name
+}
+
+// Normalize brings platform values to canonical form
+func (platform *Platform) Normalize() {
+	platform.GOOS = strings.ToLower(strings.TrimSpace(platform.GOOS))
+	platform.GOARCH = strings.ToLower(strings.TrimSpace(platform.GOARCH))
+}
+
+// Validate checks that platform is defined
+func (platform *Platform) Validate() error {
+	if platform.GOOS == "" || platform.GOARCH == "" {
+		return errors.New("platform must define both os and arch")
+	}
+	return nil
+}
+
+// Validate checks that target is defined
+func (target *Target) Validate() error {
+	if target.Name == "" {
+		return errors.New("target name is required")
+	}
+	if target.Path == "" {
+		return fmt.Errorf("path is required for target %s", target.Name)
+	}
+	if strings.ContainsAny(target.Output, "/\\") {
+		return fmt.Errorf("output of target %s must be a file name: %s", target.Name, target.Output)
+	}
+	return validateEnv(target.Env)
+}
+
+// validateEnv checks that every variable is defined as KEY=VALUE
+func validateEnv(env []string) error {
+	for _, value := range env {
+		if strings.Index(value, "=") < 1 {
+			return fmt.Errorf("invalid env variable %q, expected KEY=VALUE", value)
+		}
+	}
+	return nil
 }
 
-// Bundle defines result of tarball build
+// Bundle defines result of archive build
 type Bundle struct {
 	Target       Target
 	Platform     Platform
+	Format       string
 	BinaryFile   string
 	ChecksumFile string
 }
@@ -85,14 +276,49 @@ func (ctx *Context) IsVerbose() bool {
 	return ctx.Verbose == "true"
 }
 
+// IsNoCache returns true if build cache must not be used
+func (ctx *Context) IsNoCache() bool {
+	return ctx.NoCache == "true"
+}
+
+// IsKeepGoing returns true if build tasks should keep running after failure
+func (ctx *Context) IsKeepGoing() bool {
+	return ctx.KeepGoing == "true"
+}
+
+// MaxJobs returns maximum number of build tasks running at a time
+func (ctx *Context) MaxJobs() int {
+	if !ctx.IsParallel() {
+		return 1
+	} else if ctx.Jobs > 0 {
+		return int(ctx.Jobs)
+	}
+	return runtime.NumCPU()
+}
+
+// IsTaskLogs returns true if output of build tasks should be written to log files
+func (ctx *Context) IsTaskLogs() bool {
+	return ctx.TaskLogs == "true"
+}
+
+// FeatureFlags returns feature flags defined in config
+func (ctx *Context) FeatureFlags() flags.Flags {
+	return ctx.Flags
+}
+
 // ActivePlatforms returns list of active platforms
 func (ctx *Context) ActivePlatforms() []Platform {
+	return ctx.filterPlatforms(ctx.Platforms)
+}
+
+// filterPlatforms returns platforms listed in PLATFORMS
+func (ctx *Context) filterPlatforms(platforms []Platform) []Platform {
 	if ctx.FilterPlatforms == "-" {
-		return ctx.Platforms
+		return platforms
 	}
 	res := make([]Platform, 0)
 	usePlatforms := strings.Split(ctx.FilterPlatforms, ",")
-	for _, platform := range ctx.Platforms {
+	for _, platform := range platforms {
 		for _, usePlatform := range usePlatforms {
 			platformParts := strings.Split(usePlatform, ":")
 			if platformParts[0] == platform.GOOS && platformParts[1] == platform.GOARCH {
@@ -2,18 +2,39 @@ package build
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/Masterminds/semver"
 	"github.com/pkg/errors"
 )
 
+const (
+	VersionFormatHash     = "hash"
+	VersionFormatSemVer   = "semver"
+	VersionFormatPlain    = "plain"
+	VersionFormatSnapshot = "snapshot"
+
+	DefaultPrereleaseChannel = "rc"
+	DefaultSnapshotChannel   = "dev"
+)
+
+var (
+	VersionFormats = []string{VersionFormatHash, VersionFormatSemVer, VersionFormatPlain, VersionFormatSnapshot}
+)
+
 // SetVersionInConfig sets new version in the config file
 func (ctx *Context) SetVersionInConfig(version string) error {
 	cfg, _, err := ReadConfigFile(ctx.GetConfigFilePath())
 	if err != nil {
 		return err
 	}
+	old := *cfg
 	cfg.Version = version
-	return WriteConfigFile(ctx.GetConfigFilePath(), cfg)
+	if err := WriteConfigFile(ctx.GetConfigFilePath(), cfg); err != nil {
+		return err
+	}
+	return ctx.Auditor().Record("SetVersionInConfig", &old, cfg)
 }
 
 // SemVer returns parsed SemVer object
@@ -25,11 +46,142 @@ func (ctx *Context) SemVer() *semver.Version {
 	}
 }
 
-// FullVersion returns version + git hash
+// FullVersion returns version formatted according to the version format:
+// hash - version + git hash (1.2.0-abc1234, hash goes to build metadata of prereleases: 1.2.0-rc.1+abc1234),
+// semver - git hash as build metadata (1.2.0+abc1234), plain - version as is,
+// snapshot - snapshot version (1.2.1-dev.5+abc1234)
 func (ctx *Context) FullVersion() string {
-	hash, err := ctx.git.HashShort()
+	switch ctx.VersionFormat {
+	case VersionFormatPlain:
+		return ctx.Version
+	case VersionFormatSnapshot:
+		version, err := ctx.SnapshotVersion(DefaultSnapshotChannel)
+		if err != nil {
+			panic(err)
+		}
+		return version
+	}
+	hash, err := ctx.gitClient().HashShort()
 	if err != nil {
 		panic(errors.Wrap(err, "unable to detect Git hash"))
 	}
+	version := ctx.SemVer()
+	if ctx.VersionFormat == VersionFormatSemVer || version.Prerelease() != "" || version.Metadata() != "" {
+		return withMetadata(version, hash).String()
+	}
 	return fmt.Sprintf("%s-%s", ctx.Version, hash)
 }
+
+// PrereleaseVersion returns next prerelease version of the channel: number of the prerelease is incremented
+// if current version is the prerelease of the same channel (1.2.0-rc.1 -> 1.2.0-rc.2), first prerelease
+// of the version is started otherwise (1.2.0-beta.2 -> 1.2.0-rc.1, 1.1.0 -> 1.2.0-rc.1 if there are features
+// since the last version tag). Switching to the channel which precedes the current one (1.2.0-rc.2 -> beta)
+// is an error since the version would go backwards
+func (ctx *Context) PrereleaseVersion(channel string) (string, error) {
+	if channel == "" {
+		channel = DefaultPrereleaseChannel
+	}
+	current, err := semver.NewVersion(ctx.Version)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid version %q", ctx.Version)
+	}
+	number := 1
+	base := *current
+	if prerelease := current.Prerelease(); prerelease != "" {
+		if strings.HasPrefix(prerelease, channel+".") {
+			if n, err := strconv.Atoi(strings.TrimPrefix(prerelease, channel+".")); err == nil {
+				number = n + 1
+			}
+		}
+	} else {
+		bump, err := ctx.NextVersion()
+		if err != nil {
+			return "", err
+		}
+		next, err := semver.NewVersion(bump.Version)
+		if err != nil {
+			return "", err
+		}
+		if base = *next; !next.GreaterThan(current) {
+			base = current.IncPatch()
+		}
+	}
+	res, err := withMetadata(&base, "").SetPrerelease(fmt.Sprintf("%s.%d", channel, number))
+	if err != nil {
+		return "", err
+	} else if !res.GreaterThan(current) {
+		return "", fmt.Errorf("prerelease %s of channel %s would precede current version %s", res.String(), channel, ctx.Version)
+	}
+	return res.String(), nil
+}
+
+// FinalVersion returns version without prerelease and build metadata (1.2.0-rc.2 -> 1.2.0)
+func (ctx *Context) FinalVersion() (string, error) {
+	current, err := semver.NewVersion(ctx.Version)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid version %q", ctx.Version)
+	}
+	res, err := withMetadata(current, "").SetPrerelease("")
+	if err != nil {
+		return "", err
+	}
+	return res.String(), nil
+}
+
+// SnapshotVersion returns version of the channel derived from the number of commits made since
+// the last version tag (1.2.0 tagged 5 commits ago -> 1.2.1-dev.5+abc1234), version of the tag
+// is returned as is if HEAD is tagged
+func (ctx *Context) SnapshotVersion(channel string) (string, error) {
+	if channel == "" {
+		channel = DefaultSnapshotChannel
+	}
+	commits, tag, err := ctx.commitsSince("", "")
+	if err != nil {
+		return "", err
+	}
+	base := tag.version
+	if base == nil {
+		if base, err = semver.NewVersion(ctx.Version); err != nil {
+			return "", errors.Wrapf(err, "invalid version %q", ctx.Version)
+		}
+	}
+	if tag.version != nil && len(commits) == 0 {
+		return base.String(), nil
+	}
+	hash, err := ctx.gitClient().HashShort()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to detect Git hash")
+	}
+	// prerelease base is extended rather than bumped so that snapshot is still greater than the base
+	// (1.2.0-rc.1 tagged 5 commits ago -> 1.2.0-rc.1.dev.5+abc1234)
+	next, prerelease := base.IncPatch(), fmt.Sprintf("%s.%d", channel, len(commits))
+	if base.Prerelease() != "" {
+		next, prerelease = *base, base.Prerelease()+"."+prerelease
+	}
+	res, err := next.SetPrerelease(prerelease)
+	if err != nil {
+		return "", err
+	}
+	return withMetadata(&res, hash).String(), nil
+}
+
+func validateVersionFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range VersionFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported version format %q, expected one of %v", format, VersionFormats)
+}
+
+// withMetadata returns copy of the version with build metadata replaced
+func withMetadata(version *semver.Version, metadata string) *semver.Version {
+	res, err := version.SetMetadata(metadata)
+	if err != nil {
+		panic(errors.Wrapf(err, "invalid build metadata %q", metadata))
+	}
+	return &res
+}
@@ -0,0 +1,93 @@
+package build_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/build"
+	"github.com/smecsia/go-utils/pkg/git"
+	"github.com/smecsia/go-utils/pkg/git/mock"
+)
+
+func TestFullVersion(t *testing.T) {
+	RegisterTestingT(t)
+
+	gitMock := &mock.GitMock{}
+	gitMock.On("HashShort").Return("abc1234", nil)
+	ctx := &Context{Version: "1.2.0"}
+	ctx.SetGit(gitMock)
+
+	Expect(ctx.FullVersion()).To(Equal("1.2.0-abc1234"))
+	ctx.VersionFormat = VersionFormatSemVer
+	Expect(ctx.FullVersion()).To(Equal("1.2.0+abc1234"))
+	ctx.VersionFormat = VersionFormatPlain
+	Expect(ctx.FullVersion()).To(Equal("1.2.0"))
+
+	ctx.Version, ctx.VersionFormat = "1.2.0-rc.1", VersionFormatHash
+	Expect(ctx.FullVersion()).To(Equal("1.2.0-rc.1+abc1234"))
+	ctx.Version = "1.2.0-rc.1+linux"
+	Expect(ctx.FullVersion()).To(Equal("1.2.0-rc.1+abc1234"))
+
+	ctx.VersionFormat = "latest"
+	Expect(ctx.Validate()).To(MatchError(ContainSubstring("unsupported version format")))
+}
+
+func TestPrereleaseAndSnapshotVersions(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "version")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	repo := newTestRepo(tmpDir)
+	ctx := &Context{Version: "1.1.0", VersionFormat: VersionFormatSnapshot}
+	ctx.SetGit(git.New(tmpDir))
+
+	repo.tag("v1.1.0", repo.commit("feat: initial version"), false)
+	Expect(ctx.FullVersion()).To(Equal("1.1.0"))
+	repo.commit("fix: handle empty config")
+	head := repo.commit("feat: add endpoint")
+	Expect(ctx.FullVersion()).To(Equal("1.1.1-dev.2+" + head.String()[:7]))
+	snapshot, err := ctx.SnapshotVersion("nightly")
+	Expect(err).To(BeNil())
+	Expect(snapshot).To(HavePrefix("1.1.1-nightly.2+"))
+
+	for _, step := range []struct{ channel, from, to string }{
+		{"", "1.1.0", "1.2.0-rc.1"},
+		{"rc", "1.2.0-rc.1", "1.2.0-rc.2"},
+		{"rc", "1.2.0-beta.2", "1.2.0-rc.1"},
+		{"rc", "1.1.1+build.1", "1.2.0-rc.1"},
+	} {
+		ctx.Version = step.from
+		version, err := ctx.PrereleaseVersion(step.channel)
+		Expect(err).To(BeNil())
+		Expect(version).To(Equal(step.to))
+	}
+	for _, from := range []string{"1.2.0-rc.2", "1.2.0-rc.x"} {
+		ctx.Version = from
+		_, err := ctx.PrereleaseVersion("beta")
+		Expect(err).To(MatchError(ContainSubstring("would precede current version " + from)))
+	}
+
+	ctx.Version = "1.2.0-rc.2+abc1234"
+	version, err := ctx.FinalVersion()
+	Expect(err).To(BeNil())
+	Expect(version).To(Equal("1.2.0"))
+
+	repo.tag("v1.2.0", head, false)
+	ctx.Version = "1.2.0"
+	repo.commit("docs: describe versions")
+	version, err = ctx.PrereleaseVersion("")
+	Expect(err).To(BeNil())
+	Expect(version).To(Equal("1.2.1-rc.1"))
+
+	repo.tag("v1.3.0-rc.1", repo.commit("feat: add flags"), false)
+	repo.commit("fix: validate flags")
+	head = repo.commit("fix: document flags")
+	snapshot, err = ctx.SnapshotVersion("")
+	Expect(err).To(BeNil())
+	Expect(snapshot).To(Equal("1.3.0-rc.1.dev.2+" + head.String()[:7]))
+	Expect(semver.MustParse(snapshot).GreaterThan(semver.MustParse("1.3.0-rc.1"))).To(BeTrue())
+}
@@ -0,0 +1,91 @@
+package buildinfo
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/smecsia/go-utils/pkg/render"
+)
+
+const (
+	// FormatText prints build info as a single line
+	FormatText = "text"
+	// TextTemplate is the template of FormatText
+	TextTemplate = "template://buildinfo/version.tpl"
+)
+
+// Variables are set by the linker (see build.Context.Build), all of them are strings
+// so that they can be set with -X flag
+var (
+	Version     = "dev"
+	Commit      = ""
+	ShortCommit = ""
+	Branch      = ""
+	Dirty       = "false"
+	Date        = ""
+	Builder     = ""
+)
+
+// Info describes the binary and the way it was built
+type Info struct {
+	Version     string `json:"version" yaml:"version"`
+	Commit      string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	ShortCommit string `json:"shortCommit,omitempty" yaml:"shortCommit,omitempty"`
+	Branch      string `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Dirty       bool   `json:"dirty" yaml:"dirty"`
+	Date        string `json:"date,omitempty" yaml:"date,omitempty"`
+	Builder     string `json:"builder,omitempty" yaml:"builder,omitempty"`
+	GoVersion   string `json:"goVersion" yaml:"goVersion"`
+	Platform    string `json:"platform" yaml:"platform"`
+}
+
+// Get returns build info of the running binary
+func Get() Info {
+	return Info{
+		Version:     Version,
+		Commit:      Commit,
+		ShortCommit: ShortCommit,
+		Branch:      Branch,
+		Dirty:       Dirty == "true",
+		Date:        Date,
+		Builder:     Builder,
+		GoVersion:   runtime.Version(),
+		Platform:    fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+	}
+}
+
+// String returns build info as a single line (e.g. for kingpin's Version)
+func (info Info) String() string {
+	res := info.Version
+	if info.ShortCommit != "" {
+		res += " (" + info.ShortCommit
+		if info.Branch != "" {
+			res += ", " + info.Branch
+		}
+		if info.Dirty {
+			res += ", dirty"
+		}
+		res += ")"
+	}
+	if info.Date != "" {
+		res += " built " + info.Date
+	}
+	if info.Builder != "" {
+		res += " by " + info.Builder
+	}
+	return fmt.Sprintf("%s with %s for %s", res, info.GoVersion, info.Platform)
+}
+
+// Print writes build info of the running binary in FormatText, json, yaml or using template
+func Print(w io.Writer, format string) error {
+	return Get().Write(w, format)
+}
+
+// Write writes build info in FormatText, json, yaml or using template
+func (info Info) Write(w io.Writer, format string) error {
+	if format == "" || format == FormatText {
+		format = TextTemplate
+	}
+	return render.Write(w, format, info)
+}
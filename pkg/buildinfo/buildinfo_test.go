@@ -0,0 +1,31 @@
+package buildinfo_test
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/buildinfo"
+)
+
+func TestInfoWrite(t *testing.T) {
+	RegisterTestingT(t)
+
+	info := Info{Version: "1.2.3", ShortCommit: "abc123d", Branch: "master", Dirty: true,
+		Date: "2017-07-14T02:40:00Z", Builder: "ci", GoVersion: "go1.12", Platform: "linux/amd64"}
+	Expect(info.String()).To(Equal("1.2.3 (abc123d, master, dirty) built 2017-07-14T02:40:00Z by ci with go1.12 for linux/amd64"))
+
+	var buf bytes.Buffer
+	Expect(info.Write(&buf, FormatText)).To(BeNil())
+	Expect(buf.String()).To(Equal(info.String() + "\n"))
+
+	buf.Reset()
+	Expect(info.Write(&buf, "json")).To(BeNil())
+	Expect(buf.String()).To(ContainSubstring(`"shortCommit": "abc123d"`))
+	Expect(buf.String()).NotTo(ContainSubstring(`"commit"`))
+
+	Expect(Get().Version).To(Equal("dev"))
+	Expect(Get().GoVersion).To(Equal(runtime.Version()))
+	Expect(Get().String()).To(HavePrefix("dev with "))
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	AuditSourceConsole = "console"
+	AuditSourceReload  = "reload"
+	auditFileSuffix    = ".audit.jsonl"
+)
+
+// AuditEntry is a single record of the config audit trail
+type AuditEntry struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Config    string      `json:"config,omitempty"`
+	Path      string      `json:"path"`
+	Old       interface{} `json:"old"`
+	New       interface{} `json:"new"`
+	Source    string      `json:"source"`
+	Author    string      `json:"author,omitempty"`
+}
+
+// AuditSink stores audit entries
+type AuditSink interface {
+	Append(entries ...AuditEntry) error
+}
+
+// Audited is implemented by configs which keep audit trail of their changes
+type Audited interface {
+	Auditor() *Auditor
+}
+
+// Auditor records config changes into the sink
+type Auditor struct {
+	Sink   AuditSink
+	Author string
+}
+
+// FileAuditSink appends audit entries to the file as JSON lines
+type FileAuditSink struct {
+	Path string
+
+	mutex sync.Mutex
+}
+
+// AuditFilePath returns default path to audit log kept next to the config file
+func AuditFilePath(configPath string) string {
+	return configPath + auditFileSuffix
+}
+
+// NewFileAuditor returns auditor appending entries to the file
+func NewFileAuditor(path string, author string) *Auditor {
+	return &Auditor{Sink: &FileAuditSink{Path: path}, Author: author}
+}
+
+// Record appends all field changes between old and new config to the audit trail
+func (a *Auditor) Record(source string, old interface{}, new interface{}) error {
+	if a == nil || a.Sink == nil {
+		return nil
+	}
+	changes := Changes(old, new)
+	if len(changes) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	configPath := configFilePathOf(old, new)
+	entries := make([]AuditEntry, len(changes))
+	for i, change := range changes {
+		change = change.Redacted()
+		entries[i] = AuditEntry{
+			Timestamp: now,
+			Config:    configPath,
+			Path:      change.Path,
+			Old:       change.Old,
+			New:       change.New,
+			Source:    source,
+			Author:    a.Author,
+		}
+	}
+	return a.Sink.Append(entries...)
+}
+
+// Append writes entries to the end of the file
+func (s *FileAuditSink) Append(entries ...AuditEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordAudit records changes if config keeps audit trail
+func recordAudit(source string, old interface{}, new Config) error {
+	if audited, ok := new.(Audited); ok {
+		return audited.Auditor().Record(source, old, new)
+	}
+	return nil
+}
+
+// snapshot returns shallow copy of the config to compare it with later
+func snapshot(cfg Config) interface{} {
+	value := reflect.ValueOf(cfg)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return cfg
+	}
+	res := reflect.New(value.Elem().Type())
+	res.Elem().Set(value.Elem())
+	return res.Interface()
+}
+
+func configFilePathOf(values ...interface{}) string {
+	for _, value := range values {
+		if cfg, ok := value.(Config); ok && cfg.GetConfigFilePath() != "" {
+			return cfg.GetConfigFilePath()
+		}
+	}
+	return ""
+}
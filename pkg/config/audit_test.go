@@ -0,0 +1,122 @@
+package config_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/config"
+)
+
+type AuditedConfig struct {
+	Version   string     `yaml:"version,omitempty"`
+	Password  string     `yaml:"password,omitempty"`
+	Platforms []Platform `yaml:"platforms,omitempty"`
+
+	auditor        *Auditor
+	configFilePath string
+}
+
+func (c *AuditedConfig) SetConfigFilePath(path string) {
+	c.configFilePath = path
+}
+
+func (c *AuditedConfig) GetConfigFilePath() string {
+	return c.configFilePath
+}
+
+func (c *AuditedConfig) Init() error {
+	return nil
+}
+
+func (c *AuditedConfig) Auditor() *Auditor {
+	return c.auditor
+}
+
+func readAuditLog(filePath string) []AuditEntry {
+	f, err := os.Open(filePath)
+	Expect(err).To(BeNil())
+	defer f.Close()
+
+	res := make([]AuditEntry, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry AuditEntry
+		Expect(json.Unmarshal(scanner.Bytes(), &entry)).To(BeNil())
+		res = append(res, entry)
+	}
+	return res
+}
+
+func TestChanges(t *testing.T) {
+	RegisterTestingT(t)
+
+	old := &AuditedConfig{Version: "1.0.0", Password: "old", Platforms: []Platform{{GOOS: "linux", GOARCH: "amd64"}}}
+	updated := &AuditedConfig{Version: "1.0.1", Password: "new", Platforms: []Platform{
+		{GOOS: "linux", GOARCH: "arm64"}, {GOOS: "darwin", GOARCH: "amd64"},
+	}}
+
+	Expect(Changes(old, updated)).To(Equal([]Change{
+		{Path: "version", Old: "1.0.0", New: "1.0.1"},
+		{Path: "password", Old: "old", New: "new", Sensitive: true},
+		{Path: "platforms[0].arch", Old: "amd64", New: "arm64"},
+		{Path: "platforms[1].os", Old: nil, New: "darwin"},
+		{Path: "platforms[1].arch", Old: nil, New: "amd64"},
+	}))
+	Expect(Changes(old, old)).To(BeEmpty())
+}
+
+func TestAuditorRecordsRedactedChanges(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "audit")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	auditFile := AuditFilePath(path.Join(tmpDir, "build.yaml"))
+	auditor := NewFileAuditor(auditFile, "robot")
+	old := &AuditedConfig{Version: "1.0.0", Password: "old", configFilePath: "build.yaml"}
+	updated := &AuditedConfig{Version: "1.0.1", Password: "new", configFilePath: "build.yaml"}
+	Expect(auditor.Record("test", old, updated)).To(BeNil())
+	Expect(auditor.Record("test", updated, updated)).To(BeNil())
+
+	entries := readAuditLog(auditFile)
+	Expect(entries).To(HaveLen(2))
+	Expect(entries[0].Path).To(Equal("version"))
+	Expect(entries[0].Old).To(Equal("1.0.0"))
+	Expect(entries[0].New).To(Equal("1.0.1"))
+	Expect(entries[0].Source).To(Equal("test"))
+	Expect(entries[0].Author).To(Equal("robot"))
+	Expect(entries[0].Config).To(Equal("build.yaml"))
+	Expect(entries[1].Path).To(Equal("password"))
+	Expect(entries[1].Old).To(Equal("******"))
+	Expect(entries[1].New).To(Equal("******"))
+}
+
+func TestInitRecordsConsoleAnswers(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "audit")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	auditFile := path.Join(tmpDir, "audit.jsonl")
+	mockedReader := new(MockedReader)
+	mockedReader.On("ReadLine").Return("2.0.0")
+	mockedReader.On("ReadPassword").Return("secret")
+
+	Init("testdata/build.yaml", &AuditedConfig{auditor: NewFileAuditor(auditFile, "")}, mockedReader)
+
+	entries := readAuditLog(auditFile)
+	Expect(entries).To(HaveLen(2))
+	Expect(entries[0].Path).To(Equal("version"))
+	Expect(entries[0].New).To(Equal("2.0.0"))
+	Expect(entries[0].Source).To(Equal(AuditSourceConsole))
+	Expect(entries[0].Config).To(Equal("testdata/build.yaml"))
+	Expect(entries[1].Path).To(Equal("password"))
+	Expect(entries[1].New).To(Equal("******"))
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	sensitiveTag  = "sensitive"
+	redactedValue = "******"
+)
+
+var (
+	sensitiveFieldNames = []string{"password", "secret", "token"}
+)
+
+// Change describes modification of a single config field
+type Change struct {
+	Path      string      `json:"path" yaml:"path"`
+	Old       interface{} `json:"old" yaml:"old"`
+	New       interface{} `json:"new" yaml:"new"`
+	Sensitive bool        `json:"sensitive,omitempty" yaml:"sensitive,omitempty"`
+}
+
+// Changes returns list of field-level changes between two configs of the same type
+func Changes(old interface{}, new interface{}) []Change {
+	res := make([]Change, 0)
+	collectChanges(&res, "", reflect.ValueOf(old), reflect.ValueOf(new), false)
+	return res
+}
+
+// Redacted returns copy of the change with values hidden if change is sensitive
+func (c Change) Redacted() Change {
+	if !c.Sensitive {
+		return c
+	}
+	if c.Old != nil {
+		c.Old = redactedValue
+	}
+	if c.New != nil {
+		c.New = redactedValue
+	}
+	return c
+}
+
+func collectChanges(res *[]Change, path string, old reflect.Value, new reflect.Value, sensitive bool) {
+	old, new = indirect(old), indirect(new)
+	if !old.IsValid() && !new.IsValid() {
+		return
+	}
+	kind := valueKind(old, new)
+	switch kind {
+	case reflect.Struct:
+		typed := old
+		if !typed.IsValid() {
+			typed = new
+		}
+		valueType := typed.Type()
+		for i := 0; i < valueType.NumField(); i++ {
+			fieldType := valueType.Field(i)
+			if fieldType.PkgPath != "" {
+				continue
+			}
+			collectChanges(res, joinPath(path, fieldPathName(fieldType)),
+				fieldOf(old, i), fieldOf(new, i), sensitive || isSensitiveField(fieldType))
+		}
+	case reflect.Slice, reflect.Array:
+		length := maxInt(lengthOf(old), lengthOf(new))
+		for i := 0; i < length; i++ {
+			collectChanges(res, fmt.Sprintf("%s[%d]", path, i), indexOf(old, i), indexOf(new, i), sensitive)
+		}
+	case reflect.Map:
+		for _, key := range mapKeys(old, new) {
+			collectChanges(res, joinPath(path, key), mapIndexOf(old, key), mapIndexOf(new, key), sensitive)
+		}
+	default:
+		oldValue, newValue := interfaceOf(old), interfaceOf(new)
+		if !reflect.DeepEqual(oldValue, newValue) {
+			*res = append(*res, Change{Path: path, Old: oldValue, New: newValue, Sensitive: sensitive})
+		}
+	}
+}
+
+func isSensitiveField(fieldType reflect.StructField) bool {
+	if fieldType.Tag.Get(sensitiveTag) == "true" {
+		return true
+	}
+	name := strings.ToLower(fieldType.Name)
+	for _, sensitiveName := range sensitiveFieldNames {
+		if strings.Contains(name, sensitiveName) {
+			return true
+		}
+	}
+	return false
+}
+
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+func valueKind(old reflect.Value, new reflect.Value) reflect.Kind {
+	if old.IsValid() && new.IsValid() && old.Type() != new.Type() {
+		return reflect.Invalid
+	}
+	if old.IsValid() {
+		return old.Kind()
+	}
+	return new.Kind()
+}
+
+func fieldOf(value reflect.Value, i int) reflect.Value {
+	if !value.IsValid() {
+		return reflect.Value{}
+	}
+	return value.Field(i)
+}
+
+func lengthOf(value reflect.Value) int {
+	if !value.IsValid() {
+		return 0
+	}
+	return value.Len()
+}
+
+func indexOf(value reflect.Value, i int) reflect.Value {
+	if !value.IsValid() || i >= value.Len() {
+		return reflect.Value{}
+	}
+	return value.Index(i)
+}
+
+func mapKeys(old reflect.Value, new reflect.Value) []string {
+	keys := make(map[string]bool)
+	for _, value := range []reflect.Value{old, new} {
+		if !value.IsValid() {
+			continue
+		}
+		for _, key := range value.MapKeys() {
+			keys[fmt.Sprint(key.Interface())] = true
+		}
+	}
+	res := make([]string, 0, len(keys))
+	for key := range keys {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func mapIndexOf(value reflect.Value, key string) reflect.Value {
+	if !value.IsValid() {
+		return reflect.Value{}
+	}
+	for _, mapKey := range value.MapKeys() {
+		if fmt.Sprint(mapKey.Interface()) == key {
+			return value.MapIndex(mapKey)
+		}
+	}
+	return reflect.Value{}
+}
+
+func interfaceOf(value reflect.Value) interface{} {
+	if !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -13,9 +13,10 @@ import (
 )
 
 const (
-	envTag     = "env"
-	defaultTag = "default"
-	yamlTag    = "yaml"
+	envTag      = "env"
+	defaultTag  = "default"
+	yamlTag     = "yaml"
+	optionalTag = "optional"
 )
 
 type Config interface {
@@ -26,11 +27,13 @@ type Config interface {
 
 
 // ReadConfig reads config from console based on provided one
+// (fields tagged as optional:"true" are left empty instead of asking for them)
 func ReadConfig(defaultConfig Config, reader ConsoleReader) Config {
 	fields := reflect.ValueOf(defaultConfig).Elem()
 	for i := 0; i < fields.NumField(); i++ {
 		field := fields.Field(i)
-		if len(field.String()) == 0 && field.CanSet() {
+		optional := fields.Type().Field(i).Tag.Get(optionalTag) == "true"
+		if len(field.String()) == 0 && field.CanSet() && !optional {
 			fieldName := fields.Type().Field(i).Name
 			fmt.Printf("Enter %s [%s]: ", fieldName, field)
 			var text string
@@ -65,13 +68,21 @@ func AddDefaults(rawConfig map[string]interface{}, newConfig Config) Config {
 
 // AddEnv sets values from env (if any)
 func AddEnv(newConfig Config) Config {
+	return AddEnvFrom(newConfig, os.Getenv)
+}
+
+// AddEnvFrom sets values from env variables resolved by the provided lookup function
+func AddEnvFrom(newConfig Config, getEnv func(string) string) Config {
 	fieldsOld := reflect.ValueOf(newConfig).Elem()
 	typeOfConfig := reflect.TypeOf(newConfig).Elem()
 	for i := 0; i < fieldsOld.NumField(); i++ {
 		field := fieldsOld.Field(i)
 		fieldType := typeOfConfig.Field(i)
 		envVarName := fieldType.Tag.Get(envTag)
-		envValue := os.Getenv(envVarName)
+		if envVarName == "" {
+			continue
+		}
+		envValue := getEnv(envVarName)
 		if envValue != "" {
 			setFieldValue(field, envValue)
 		}
@@ -85,7 +96,12 @@ func getYamlFieldName(fieldType reflect.StructField) string {
 
 // DefaultConfig Returns default version of Config file
 func DefaultConfig(cfgObj Config) Config {
-	return AddDefaults(map[string]interface{}{}, cfgObj)
+	if err := RunBeforeDefaults(cfgObj); err != nil {
+		panic(err)
+	}
+	cfg := AddDefaults(map[string]interface{}{}, cfgObj)
+	Normalize(cfg)
+	return cfg
 }
 
 func setFieldValue(field reflect.Value, valueString string) {
@@ -102,30 +118,68 @@ func setFieldValue(field reflect.Value, valueString string) {
 		}
 	} else if isBoolType(field) {
 		field.Set(reflect.ValueOf(valueString == "true"))
+	} else if isDurationType(field) && valueString != "" {
+		if duration, e := ParseDuration(valueString); e != nil {
+			fmt.Println(fmt.Sprintf("WARN: '%s' is not duration", valueString))
+		} else {
+			field.Set(reflect.ValueOf(duration))
+		}
+	} else if isByteSizeType(field) && valueString != "" {
+		if size, e := ParseByteSize(valueString); e != nil {
+			fmt.Println(fmt.Sprintf("WARN: '%s' is not size", valueString))
+		} else {
+			field.Set(reflect.ValueOf(size))
+		}
 	}
 
 }
 
-// ReadConfigFile Reads config file from yaml file
+// ReadConfigFile Reads config file from yaml file (missing file is treated as empty, use Discovery to require it)
 func ReadConfigFile(filePath string, readConfig Config) (Config, map[string]interface{}, error) {
 	rawConfig := make(map[string]interface{})
 	if fileBytes, err := ioutil.ReadFile(filePath); err == nil {
-		err = yaml.Unmarshal(fileBytes, readConfig)
-		if err != nil {
-			return readConfig, rawConfig, err
-		}
-		err = yaml.Unmarshal(fileBytes, &rawConfig)
-		if err != nil {
+		if rawConfig, err = unmarshalConfig(fileBytes, readConfig); err != nil {
 			return readConfig, rawConfig, err
 		}
+	} else if !os.IsNotExist(err) {
+		return readConfig, rawConfig, err
 	}
 	readConfig.SetConfigFilePath(filePath)
-	return readConfig, rawConfig, nil
+	return readConfig, rawConfig, RunAfterFile(readConfig)
+}
+
+// ReadConfigSource reads config from the provided source
+func ReadConfigSource(source Source, readConfig Config) (Config, map[string]interface{}, error) {
+	rawConfig := make(map[string]interface{})
+	data, err := source.Load()
+	if err != nil {
+		return readConfig, rawConfig, err
+	}
+	if rawConfig, err = unmarshalConfig(data, readConfig); err != nil {
+		return readConfig, rawConfig, err
+	}
+	readConfig.SetConfigFilePath(source.Name())
+	return readConfig, rawConfig, RunAfterFile(readConfig)
+}
+
+func unmarshalConfig(data []byte, readConfig Config) (map[string]interface{}, error) {
+	rawConfig := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &rawConfig); err != nil {
+		return rawConfig, err
+	}
+	data, err := normalizeDurations(data, rawConfig, reflect.TypeOf(readConfig))
+	if err != nil {
+		return rawConfig, err
+	}
+	if err := yaml.Unmarshal(data, readConfig); err != nil {
+		return rawConfig, err
+	}
+	return rawConfig, nil
 }
 
 // Writes config file to yaml file
 func WriteConfigFile(filePath string, cfg Config) error {
-	if fileBytes, err := yaml.Marshal(cfg); err != nil {
+	if fileBytes, err := marshalConfig(cfg); err != nil {
 		return err
 	} else if err := ioutil.WriteFile(filePath, fileBytes, os.ModePerm); err != nil {
 		return err
@@ -139,13 +193,72 @@ func Init(filePath string, cfgObj Config, reader ConsoleReader) Config {
 	if err != nil {
 		panic(err)
 	}
-	cfg := ReadConfig(AddEnv(AddDefaults(rawConfig, config)), reader)
-	if err := cfg.Init(); err != nil {
+	cfg, err := initConfig(config, rawConfig, reader, os.Getenv)
+	if err != nil {
 		panic(err)
 	}
 	return cfg
 }
 
+// InitSource reads config from the source (read only once, so verification of the source covers
+// everything that is loaded) and adds defaults from env or default tags
+func InitSource(source Source, cfgObj Config, reader ConsoleReader) Config {
+	config, rawConfig, err := ReadConfigSource(source, cfgObj)
+	if err != nil {
+		panic(err)
+	}
+	cfg, err := initConfig(config, rawConfig, reader, os.Getenv)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// Load reads config from the source and adds defaults from env or default tags without asking for input
+func Load(source Source, cfgObj Config) (Config, error) {
+	config, rawConfig, err := ReadConfigSource(source, cfgObj)
+	if err != nil {
+		return config, err
+	}
+	return initConfig(config, rawConfig, nil, os.Getenv)
+}
+
+// LoadProfile reads config from the source applying env variables from the profile instead of process env
+func LoadProfile(source Source, cfgObj Config, profile Profile) (Config, error) {
+	config, rawConfig, err := ReadConfigSource(source, cfgObj)
+	if err != nil {
+		return config, err
+	}
+	return initConfig(config, rawConfig, nil, profile.Getenv)
+}
+
+// initConfig runs loading pipeline: defaults, env, console input (if reader is provided), normalization,
+// validation and finally Init(), invoking lifecycle hooks in between
+func initConfig(config Config, rawConfig map[string]interface{}, reader ConsoleReader, getEnv func(string) string) (Config, error) {
+	if err := RunBeforeDefaults(config); err != nil {
+		return config, err
+	}
+	cfg := AddEnvFrom(AddDefaults(rawConfig, config), getEnv)
+	if err := RunAfterEnv(cfg); err != nil {
+		return cfg, err
+	}
+	if reader != nil {
+		before := snapshot(cfg)
+		cfg = ReadConfig(cfg, reader)
+		if err := recordAudit(AuditSourceConsole, before, cfg); err != nil {
+			return cfg, err
+		}
+	}
+	Normalize(cfg)
+	if err := Validate(cfg); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Init(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
 func isStringType(field reflect.Value) bool {
 	return field.Type() == reflect.TypeOf("")
 }
@@ -157,3 +270,11 @@ func isIntType(field reflect.Value) bool {
 func isBoolType(field reflect.Value) bool {
 	return field.Type() == reflect.TypeOf(false)
 }
+
+func isDurationType(field reflect.Value) bool {
+	return field.Type() == durationType
+}
+
+func isByteSizeType(field reflect.Value) bool {
+	return field.Type() == byteSizeType
+}
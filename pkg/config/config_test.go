@@ -33,6 +33,7 @@ type TestConfig struct {
 	// env-only fields
 	IsParallel  bool   `yaml:"-" default:"true" env:"PARALLEL"`
 	IsSkipTests string `yaml:"-" default:"false" env:"SKIP_TESTS"`
+	Token       string `yaml:"-" default:"" env:"TOKEN" optional:"true"`
 
 	// default-only fields
 	configFilePath string
@@ -111,6 +112,8 @@ func TestReadConfig(t *testing.T) {
 	Expect(config.TrebuchetURL).To(Equal(defaultConfig.TrebuchetURL))
 	Expect(config.IsParallel).To(Equal(true))
 	Expect(config.IsSkipTests).To(Equal("true"))
+	Expect(config.Token).To(BeEmpty())
+	mockedReader.AssertNumberOfCalls(t, "ReadLine", 1)
 }
 
 func TestItShouldNotReadVersionIfSetInEnvVar(t *testing.T) {
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/smecsia/go-utils/pkg/render"
+)
+
+const (
+	DiffFormatText = "text"
+	diffTemplate   = "template://config/diff.tpl"
+
+	opAdded   = "+"
+	opRemoved = "-"
+	opChanged = "~"
+)
+
+// Diff is a field-level difference between two configs
+type Diff struct {
+	From    string   `json:"from" yaml:"from"`
+	To      string   `json:"to" yaml:"to"`
+	Changes []Change `json:"changes" yaml:"changes"`
+}
+
+// Profile is a set of env variables config is loaded with
+type Profile map[string]string
+
+// ReadProfile reads profile from file with KEY=VALUE lines (empty lines and lines starting with # are ignored)
+func ReadProfile(filePath string) (Profile, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := make(Profile)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2)
+		if len(parts) == 2 {
+			res[strings.TrimSpace(parts[0])] = strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+		}
+	}
+	return res, scanner.Err()
+}
+
+// Getenv returns value of env variable defined in profile
+func (p Profile) Getenv(name string) string {
+	return p[name]
+}
+
+// DiffConfigs returns redacted field-level difference between two configs
+func DiffConfigs(from Config, to Config) Diff {
+	changes := Changes(from, to)
+	for i := range changes {
+		changes[i] = changes[i].Redacted()
+	}
+	return Diff{From: from.GetConfigFilePath(), To: to.GetConfigFilePath(), Changes: changes}
+}
+
+// DiffFiles returns difference between contents of two config files (without defaults and env applied)
+func DiffFiles(fromPath string, toPath string, newConfig func() Config) (Diff, error) {
+	from, _, err := ReadConfigSource(NewFileSource(fromPath), newConfig())
+	if err != nil {
+		return Diff{}, err
+	}
+	to, _, err := ReadConfigSource(NewFileSource(toPath), newConfig())
+	if err != nil {
+		return Diff{}, err
+	}
+	return DiffConfigs(from, to), nil
+}
+
+// DiffEffective returns difference between contents of config file and its effective state
+// after defaults and env have been applied
+func DiffEffective(filePath string, newConfig func() Config) (Diff, error) {
+	from, _, err := ReadConfigSource(NewFileSource(filePath), newConfig())
+	if err != nil {
+		return Diff{}, err
+	}
+	to, err := Load(NewFileSource(filePath), newConfig())
+	if err != nil {
+		return Diff{}, err
+	}
+	diff := DiffConfigs(from, to)
+	diff.To = filePath + " (effective)"
+	return diff, nil
+}
+
+// DiffProfiles returns difference between the same config file loaded with two different profiles
+func DiffProfiles(filePath string, fromProfile Profile, toProfile Profile, newConfig func() Config) (Diff, error) {
+	from, err := LoadProfile(NewFileSource(filePath), newConfig(), fromProfile)
+	if err != nil {
+		return Diff{}, err
+	}
+	to, err := LoadProfile(NewFileSource(filePath), newConfig(), toProfile)
+	if err != nil {
+		return Diff{}, err
+	}
+	return DiffConfigs(from, to), nil
+}
+
+// WriteDiff writes diff in text, json, yaml or any other format supported by render.Write
+func WriteDiff(w io.Writer, format string, diff Diff) error {
+	if format == DiffFormatText {
+		format = diffTemplate
+	}
+	return render.Write(w, format, diff)
+}
+
+// Op returns kind of change: "+" for added, "-" for removed and "~" for changed value
+func (c Change) Op() string {
+	if c.Old == nil {
+		return opAdded
+	} else if c.New == nil {
+		return opRemoved
+	}
+	return opChanged
+}
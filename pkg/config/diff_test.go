@@ -0,0 +1,92 @@
+package config_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/config"
+)
+
+func newTestConfig() Config {
+	return &TestConfig{}
+}
+
+func TestDiffFiles(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "diff")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	toPath := path.Join(tmpDir, "build.yaml")
+	Expect(ioutil.WriteFile(toPath, []byte(`
+armoryURL: http://armory.local
+platforms:
+  - os: linux
+    arch: arm64
+targets:
+  - name: deployments
+    path: cmd/deployments/main.go
+`), 0644)).To(BeNil())
+
+	diff, err := DiffFiles("testdata/build.yaml", toPath, newTestConfig)
+	Expect(err).To(BeNil())
+	Expect(diff.From).To(Equal("testdata/build.yaml"))
+	Expect(diff.To).To(Equal(toPath))
+	Expect(diff.Changes).To(Equal([]Change{
+		{Path: "platforms[0].arch", Old: "amd64", New: "arm64"},
+		{Path: "platforms[1].os", Old: "darwin"},
+		{Path: "platforms[1].arch", Old: "amd64"},
+	}))
+
+	var buf bytes.Buffer
+	Expect(WriteDiff(&buf, DiffFormatText, diff)).To(BeNil())
+	Expect(buf.String()).To(Equal(`--- testdata/build.yaml
++++ ` + toPath + `
+~ platforms[0].arch: amd64 -> arm64
+- platforms[1].os: darwin
+- platforms[1].arch: amd64
+`))
+}
+
+func TestDiffEffective(t *testing.T) {
+	RegisterTestingT(t)
+
+	diff, err := DiffEffective("testdata/build.yaml", newTestConfig)
+	Expect(err).To(BeNil())
+	Expect(diff.To).To(Equal("testdata/build.yaml (effective)"))
+	Expect(diff.Changes).To(ContainElement(Change{Path: "outDir", Old: "", New: "bin"}))
+	for _, change := range diff.Changes {
+		Expect(change.Path).NotTo(Equal("armoryURL"))
+	}
+}
+
+func TestDiffProfiles(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "diff")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	profilePath := path.Join(tmpDir, "ci.env")
+	Expect(ioutil.WriteFile(profilePath, []byte("# CI settings\nOUT_DIR=dist\nexport SKIP_TESTS=\"true\"\n"), 0644)).To(BeNil())
+
+	ci, err := ReadProfile(profilePath)
+	Expect(err).To(BeNil())
+	Expect(ci).To(Equal(Profile{"OUT_DIR": "dist", "SKIP_TESTS": "true"}))
+
+	diff, err := DiffProfiles("testdata/build.yaml", Profile{}, ci, newTestConfig)
+	Expect(err).To(BeNil())
+	Expect(diff.Changes).To(Equal([]Change{
+		{Path: "outDir", Old: "bin", New: "dist"},
+		{Path: "IsSkipTests", Old: "false", New: "true"},
+	}))
+
+	var buf bytes.Buffer
+	Expect(WriteDiff(&buf, "json", diff)).To(BeNil())
+	Expect(buf.String()).To(ContainSubstring(`"path": "outDir"`))
+}
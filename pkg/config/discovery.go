@@ -0,0 +1,217 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	LocationExplicit = "explicit"
+	LocationEnv      = "env"
+	LocationUpward   = "upward"
+	LocationXDG      = "xdg"
+	LocationHome     = "home"
+	LocationSystem   = "system"
+
+	systemConfigDir = "/etc"
+)
+
+var (
+	// DefaultLocations is the order config files are searched in (from the highest priority to the lowest)
+	DefaultLocations = []string{LocationExplicit, LocationEnv, LocationUpward, LocationXDG, LocationHome, LocationSystem}
+)
+
+// Discovery describes where to look for config files
+type Discovery struct {
+	// App is the name of application used for XDG, home and system locations (e.g. ~/.<app>/<file>)
+	App string
+	// FileName is the name of the config file (e.g. build.yaml)
+	FileName string
+	// Path is the explicitly provided path to the config file (e.g. from command line)
+	Path string
+	// EnvVar is the name of env variable with path to the config file
+	EnvVar string
+	// WorkDir is the directory upward search starts from (current dir by default)
+	WorkDir string
+	// Locations to search in order of priority (DefaultLocations if empty)
+	Locations []string
+	// Merge merges all found files (files with higher priority override values) instead of picking the first one
+	Merge bool
+	// Required makes discovery fail if no file was found
+	Required bool
+}
+
+// DiscoveredSource reads config from files found by discovery
+type DiscoveredSource struct {
+	// Paths of used files from the highest priority to the lowest
+	Paths []string
+}
+
+// Discover searches for config files and returns source reading them.
+// Explicitly provided path (or path from env variable) must exist, other locations are optional
+func (d Discovery) Discover() (*DiscoveredSource, error) {
+	var searched, found []string
+	for _, location := range d.locations() {
+		path, explicit, err := d.candidate(location)
+		if err != nil {
+			return nil, err
+		} else if path == "" {
+			continue
+		}
+		searched = append(searched, path)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if explicit {
+				return nil, errors.Errorf("config file %s (%s) does not exist", path, location)
+			}
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to check config file %s", path)
+		}
+		found = append(found, path)
+		if !d.Merge {
+			break
+		}
+	}
+	if len(found) == 0 && d.Required {
+		return nil, errors.Errorf("config file %s not found, searched in: %s", d.FileName, strings.Join(searched, ", "))
+	}
+	return &DiscoveredSource{Paths: found}, nil
+}
+
+func (d Discovery) locations() []string {
+	if len(d.Locations) == 0 {
+		return DefaultLocations
+	}
+	return d.Locations
+}
+
+// candidate returns path to look for config at the location and whether it was provided explicitly
+func (d Discovery) candidate(location string) (string, bool, error) {
+	switch location {
+	case LocationExplicit:
+		return d.Path, true, nil
+	case LocationEnv:
+		if d.EnvVar == "" {
+			return "", true, nil
+		}
+		return os.Getenv(d.EnvVar), true, nil
+	case LocationUpward:
+		workDir := d.WorkDir
+		if workDir == "" {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return "", false, err
+			}
+			workDir = cwd
+		}
+		if path, err := FindUpward(workDir, d.FileName); err == nil {
+			return path, false, nil
+		}
+		return filepath.Join(workDir, d.FileName), false, nil
+	case LocationXDG:
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return "", false, nil
+			}
+			configHome = filepath.Join(homeDir, ".config")
+		}
+		return d.appPath(configHome, d.App), false, nil
+	case LocationHome:
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", false, nil
+		}
+		return d.appPath(homeDir, "."+d.App), false, nil
+	case LocationSystem:
+		return d.appPath(systemConfigDir, d.App), false, nil
+	}
+	return "", false, errors.Errorf("unknown config location: %s", location)
+}
+
+func (d Discovery) appPath(dir string, appDir string) string {
+	if d.App == "" {
+		return ""
+	}
+	return filepath.Join(dir, appDir, d.FileName)
+}
+
+// FindUpward looks for the file in the directory and all of its parents returning path to the first one found
+func FindUpward(dir string, fileName string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, fileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.Errorf("%s not found in %s or any of its parents", fileName, dir)
+		}
+		dir = parent
+	}
+}
+
+// Name returns path to the file with the highest priority
+func (s *DiscoveredSource) Name() string {
+	if len(s.Paths) == 0 {
+		return ""
+	}
+	return s.Paths[0]
+}
+
+// Load reads found files merging them if there are many
+func (s *DiscoveredSource) Load() ([]byte, error) {
+	if len(s.Paths) == 0 {
+		return nil, nil
+	} else if len(s.Paths) == 1 {
+		return ioutil.ReadFile(s.Paths[0])
+	}
+	merged := yaml.MapSlice{}
+	for i := len(s.Paths) - 1; i >= 0; i-- {
+		data, err := ioutil.ReadFile(s.Paths[i])
+		if err != nil {
+			return nil, err
+		}
+		doc := yaml.MapSlice{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse config file %s", s.Paths[i])
+		}
+		merged = mergeDocs(merged, doc)
+	}
+	return yaml.Marshal(merged)
+}
+
+// mergeDocs deeply merges override into base (lists and scalars are replaced)
+func mergeDocs(base yaml.MapSlice, override yaml.MapSlice) yaml.MapSlice {
+	for _, item := range override {
+		replaced := false
+		for i := range base {
+			if base[i].Key != item.Key {
+				continue
+			}
+			baseValue, baseIsDoc := base[i].Value.(yaml.MapSlice)
+			value, isDoc := item.Value.(yaml.MapSlice)
+			if baseIsDoc && isDoc {
+				base[i].Value = mergeDocs(baseValue, value)
+			} else {
+				base[i].Value = item.Value
+			}
+			replaced = true
+			break
+		}
+		if !replaced {
+			base = append(base, item)
+		}
+	}
+	return base
+}
@@ -0,0 +1,85 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/config"
+)
+
+func writeTestFile(filePath string, content string) {
+	Expect(os.MkdirAll(filepath.Dir(filePath), os.ModePerm)).To(BeNil())
+	Expect(ioutil.WriteFile(filePath, []byte(content), 0644)).To(BeNil())
+}
+
+func TestDiscoverUpwardAndHome(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "discovery")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("HOME", path.Join(tmpDir, "home"))
+	os.Setenv("XDG_CONFIG_HOME", path.Join(tmpDir, "xdg"))
+
+	projectFile := path.Join(tmpDir, "project", "app.yaml")
+	homeFile := path.Join(tmpDir, "home", ".app", "app.yaml")
+	writeTestFile(projectFile, "outDir: project\nversion: 1.0.0\n")
+	writeTestFile(homeFile, "outDir: home\narmoryURL: http://home\n")
+	workDir := path.Join(tmpDir, "project", "cmd", "app")
+	Expect(os.MkdirAll(workDir, os.ModePerm)).To(BeNil())
+
+	discovery := Discovery{App: "app", FileName: "app.yaml", WorkDir: workDir}
+	source, err := discovery.Discover()
+	Expect(err).To(BeNil())
+	Expect(source.Paths).To(Equal([]string{projectFile}))
+
+	discovery.Merge = true
+	source, err = discovery.Discover()
+	Expect(err).To(BeNil())
+	Expect(source.Paths).To(Equal([]string{projectFile, homeFile}))
+	Expect(source.Name()).To(Equal(projectFile))
+
+	cfg, err := Load(source, &TestConfig{})
+	Expect(err).To(BeNil())
+	Expect(cfg.(*TestConfig).OutDir).To(Equal("project"))
+	Expect(cfg.(*TestConfig).Version).To(Equal("1.0.0"))
+	Expect(cfg.(*TestConfig).ArmoryURL).To(Equal("http://home"))
+	Expect(cfg.GetConfigFilePath()).To(Equal(projectFile))
+}
+
+func TestDiscoverExplicitAndRequired(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "discovery")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	defer os.Setenv("APP_CONFIG", "")
+
+	_, err = Discovery{FileName: "app.yaml", Path: path.Join(tmpDir, "missing.yaml")}.Discover()
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("missing.yaml (explicit) does not exist"))
+
+	envFile := path.Join(tmpDir, "env.yaml")
+	writeTestFile(envFile, "outDir: env\n")
+	os.Setenv("APP_CONFIG", envFile)
+	source, err := Discovery{FileName: "app.yaml", EnvVar: "APP_CONFIG", WorkDir: tmpDir}.Discover()
+	Expect(err).To(BeNil())
+	Expect(source.Paths).To(Equal([]string{envFile}))
+
+	os.Setenv("APP_CONFIG", "")
+	source, err = Discovery{FileName: "app.yaml", EnvVar: "APP_CONFIG", WorkDir: tmpDir,
+		Locations: []string{LocationEnv, LocationUpward}}.Discover()
+	Expect(err).To(BeNil())
+	Expect(source.Paths).To(BeEmpty())
+
+	_, err = Discovery{FileName: "app.yaml", WorkDir: tmpDir, Required: true,
+		Locations: []string{LocationUpward}}.Discover()
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("config file app.yaml not found, searched in: " + path.Join(tmpDir, "app.yaml")))
+}
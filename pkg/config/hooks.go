@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// BeforeDefaultsHook is called before default values are set into config
+type BeforeDefaultsHook interface {
+	BeforeDefaults() error
+}
+
+// AfterFileHook is called right after config has been read from file (or any other source)
+type AfterFileHook interface {
+	AfterFile() error
+}
+
+// AfterEnvHook is called after values from env have been applied
+type AfterEnvHook interface {
+	AfterEnv() error
+}
+
+// Normalizer is called when all values are set to bring them to canonical form
+type Normalizer interface {
+	Normalize()
+}
+
+// Validator is called after normalization to verify config is consistent
+type Validator interface {
+	Validate() error
+}
+
+var (
+	beforeDefaultsHookType = reflect.TypeOf((*BeforeDefaultsHook)(nil)).Elem()
+	afterFileHookType      = reflect.TypeOf((*AfterFileHook)(nil)).Elem()
+	afterEnvHookType       = reflect.TypeOf((*AfterEnvHook)(nil)).Elem()
+	normalizerType         = reflect.TypeOf((*Normalizer)(nil)).Elem()
+	validatorType          = reflect.TypeOf((*Validator)(nil)).Elem()
+)
+
+// RunBeforeDefaults invokes BeforeDefaults hooks on config and all its nested structs
+func RunBeforeDefaults(cfg Config) error {
+	return runHooks(cfg, beforeDefaultsHookType, func(hook interface{}) error {
+		return hook.(BeforeDefaultsHook).BeforeDefaults()
+	})
+}
+
+// RunAfterFile invokes AfterFile hooks on config and all its nested structs
+func RunAfterFile(cfg Config) error {
+	return runHooks(cfg, afterFileHookType, func(hook interface{}) error {
+		return hook.(AfterFileHook).AfterFile()
+	})
+}
+
+// RunAfterEnv invokes AfterEnv hooks on config and all its nested structs
+func RunAfterEnv(cfg Config) error {
+	return runHooks(cfg, afterEnvHookType, func(hook interface{}) error {
+		return hook.(AfterEnvHook).AfterEnv()
+	})
+}
+
+// Normalize invokes Normalize hooks on config and all its nested structs
+func Normalize(cfg Config) {
+	_ = runHooks(cfg, normalizerType, func(hook interface{}) error {
+		hook.(Normalizer).Normalize()
+		return nil
+	})
+}
+
+// Validate invokes Validate hooks on config and all its nested structs
+func Validate(cfg Config) error {
+	return runHooks(cfg, validatorType, func(hook interface{}) error {
+		return hook.(Validator).Validate()
+	})
+}
+
+// runHooks walks config depth-first calling hook on nested values before their parents
+func runHooks(cfg Config, hookType reflect.Type, hook func(interface{}) error) error {
+	return walkHooks(reflect.ValueOf(cfg), "", hookType, hook)
+}
+
+func walkHooks(value reflect.Value, path string, hookType reflect.Type, hook func(interface{}) error) error {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return walkHooks(value.Elem(), path, hookType, hook)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			fieldType := value.Type().Field(i)
+			if fieldType.PkgPath != "" {
+				continue
+			}
+			if err := walkHooks(value.Field(i), joinPath(path, fieldPathName(fieldType)), hookType, hook); err != nil {
+				return err
+			}
+		}
+		if value.CanAddr() {
+			return callHook(value.Addr(), path, hookType, hook)
+		}
+		return callHook(value, path, hookType, hook)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := walkHooks(value.Index(i), fmt.Sprintf("%s[%d]", path, i), hookType, hook); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// values of maps are not addressable, so hooks are only called on the map itself
+		return callHook(value, path, hookType, hook)
+	}
+	return nil
+}
+
+func callHook(value reflect.Value, path string, hookType reflect.Type, hook func(interface{}) error) error {
+	if !value.Type().Implements(hookType) {
+		return nil
+	}
+	if err := hook(value.Interface()); err != nil {
+		if path == "" {
+			return err
+		}
+		return errors.Wrap(err, path)
+	}
+	return nil
+}
+
+func fieldPathName(fieldType reflect.StructField) string {
+	if name := getYamlFieldName(fieldType); name != "" && name != "-" {
+		return name
+	}
+	return fieldType.Name
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
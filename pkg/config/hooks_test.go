@@ -0,0 +1,119 @@
+package config_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/config"
+)
+
+type hookCalls struct {
+	calls []string
+}
+
+func (h *hookCalls) add(call string) {
+	h.calls = append(h.calls, call)
+}
+
+type HookedItem struct {
+	Name string `yaml:"name,omitempty"`
+}
+
+func (i *HookedItem) Normalize() {
+	i.Name = strings.ToLower(i.Name)
+}
+
+func (i *HookedItem) Validate() error {
+	if i.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type HookedConfig struct {
+	OutDir string       `yaml:"outDir,omitempty" env:"OUT_DIR" default:"bin"`
+	Items  []HookedItem `yaml:"items,omitempty"`
+
+	hooks          *hookCalls
+	configFilePath string
+}
+
+func (c *HookedConfig) SetConfigFilePath(path string) {
+	c.configFilePath = path
+}
+
+func (c *HookedConfig) GetConfigFilePath() string {
+	return c.configFilePath
+}
+
+func (c *HookedConfig) AfterFile() error {
+	c.hooks.add("AfterFile:" + c.OutDir)
+	return nil
+}
+
+func (c *HookedConfig) BeforeDefaults() error {
+	c.hooks.add("BeforeDefaults:" + c.OutDir)
+	return nil
+}
+
+func (c *HookedConfig) AfterEnv() error {
+	c.hooks.add("AfterEnv:" + c.OutDir)
+	return nil
+}
+
+func (c *HookedConfig) Normalize() {
+	c.hooks.add("Normalize")
+}
+
+func (c *HookedConfig) Validate() error {
+	c.hooks.add("Validate")
+	return nil
+}
+
+func (c *HookedConfig) Init() error {
+	c.hooks.add("Init")
+	return nil
+}
+
+type bytesSource string
+
+func (s bytesSource) Name() string {
+	return "bytes"
+}
+
+func (s bytesSource) Load() ([]byte, error) {
+	return []byte(s), nil
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	RegisterTestingT(t)
+
+	hooks := &hookCalls{}
+	cfg, err := Load(bytesSource("items:\n- name: First\n"), &HookedConfig{hooks: hooks})
+	Expect(err).To(BeNil())
+	Expect(hooks.calls).To(Equal([]string{
+		"AfterFile:", "BeforeDefaults:", "AfterEnv:bin", "Normalize", "Validate", "Init",
+	}))
+	Expect(cfg.(*HookedConfig).Items[0].Name).To(Equal("first"))
+}
+
+func TestNestedValidationFails(t *testing.T) {
+	RegisterTestingT(t)
+
+	hooks := &hookCalls{}
+	_, err := Load(bytesSource("items:\n- name: first\n- {}\n"), &HookedConfig{hooks: hooks})
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(Equal("items[1]: name is required"))
+	Expect(hooks.calls).NotTo(ContainElement("Init"))
+}
+
+func TestDefaultConfigRunsHooks(t *testing.T) {
+	RegisterTestingT(t)
+
+	hooks := &hookCalls{}
+	cfg := DefaultConfig(&HookedConfig{hooks: hooks}).(*HookedConfig)
+	Expect(cfg.OutDir).To(Equal("bin"))
+	Expect(hooks.calls).To(Equal([]string{"BeforeDefaults:", "Normalize"}))
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+	headerPrefer      = "Prefer"
+	headerConsulIndex = "X-Consul-Index"
+)
+
+// HTTPSource reads config from remote HTTP endpoint (plain URL or Consul KV API)
+type HTTPSource struct {
+	URL          string
+	Header       http.Header
+	Timeout      time.Duration
+	Retries      int
+	RetryDelay   time.Duration
+	CacheFile    string
+	Consul       bool
+	WaitTime     time.Duration
+	PollInterval time.Duration
+	Client       *http.Client
+
+	mutex sync.Mutex
+	etag  string
+	index string
+	body  []byte
+}
+
+// NewHTTPSource returns source fetching config from the URL
+func NewHTTPSource(url string) *HTTPSource {
+	return &HTTPSource{
+		URL:          url,
+		Header:       http.Header{},
+		Timeout:      10 * time.Second,
+		Retries:      3,
+		RetryDelay:   time.Second,
+		WaitTime:     5 * time.Minute,
+		PollInterval: 30 * time.Second,
+		Client:       http.DefaultClient,
+	}
+}
+
+// NewConsulSource returns source fetching config from Consul KV API
+func NewConsulSource(address string, key string) *HTTPSource {
+	source := NewHTTPSource(fmt.Sprintf("%s/v1/kv/%s", address, key))
+	source.Consul = true
+	return source
+}
+
+// Name returns URL of the config
+func (s *HTTPSource) Name() string {
+	return s.URL
+}
+
+// Load fetches config retrying on failures and falls back to the cache file if endpoint is unavailable
+func (s *HTTPSource) Load() ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= s.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(s.RetryDelay)
+		}
+		var data []byte
+		if data, _, err = s.fetch(context.Background(), s.Timeout, false); err == nil {
+			return data, nil
+		}
+	}
+	if s.CacheFile == "" {
+		return nil, err
+	}
+	data, cacheErr := ioutil.ReadFile(s.CacheFile)
+	if cacheErr != nil {
+		return nil, errors.Wrapf(err, "failed to read cache (%s)", cacheErr)
+	}
+	fmt.Println(fmt.Sprintf("WARN: failed to fetch config from %s, using cached %s: %s", s.URL, s.CacheFile, err))
+	return data, nil
+}
+
+// Watch long-polls endpoint calling changed every time config document has changed
+func (s *HTTPSource) Watch(stop <-chan struct{}, changed func(data []byte)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+	for {
+		data, modified, err := s.fetch(ctx, s.Timeout+s.WaitTime, true)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Println(fmt.Sprintf("WARN: failed to watch config at %s: %s", s.URL, err))
+		} else if modified {
+			changed(data)
+		}
+		delay := s.PollInterval
+		if err != nil {
+			delay = s.RetryDelay
+		} else if s.Consul {
+			delay = 0
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *HTTPSource) fetch(ctx context.Context, timeout time.Duration, wait bool) ([]byte, bool, error) {
+	s.mutex.Lock()
+	etag, index := s.etag, s.index
+	s.mutex.Unlock()
+
+	reqURL, err := s.requestURL(wait, index)
+	if err != nil {
+		return nil, false, err
+	}
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	for name, values := range s.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if etag != "" {
+		req.Header.Set(headerIfNoneMatch, etag)
+		if wait && !s.Consul {
+			req.Header.Set(headerPrefer, fmt.Sprintf("wait=%d", int(s.WaitTime.Seconds())))
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	resp, err := s.client().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to fetch config from %s", s.URL)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return s.body, false, nil
+	case http.StatusOK:
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to read config from %s", s.URL)
+		}
+		s.mutex.Lock()
+		modified := s.body != nil && !bytes.Equal(s.body, body)
+		s.body = body
+		s.etag = resp.Header.Get(headerETag)
+		s.index = resp.Header.Get(headerConsulIndex)
+		s.mutex.Unlock()
+		if err := s.writeCache(body); err != nil {
+			fmt.Println(fmt.Sprintf("WARN: failed to write config cache %s: %s", s.CacheFile, err))
+		}
+		return body, modified, nil
+	default:
+		return nil, false, fmt.Errorf("failed to fetch config from %s: unexpected status %s", s.URL, resp.Status)
+	}
+}
+
+func (s *HTTPSource) requestURL(wait bool, index string) (string, error) {
+	if !s.Consul {
+		return s.URL, nil
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return "", errors.Wrapf(err, "bad config URL: %q", s.URL)
+	}
+	query := u.Query()
+	query.Set("raw", "")
+	if wait && index != "" {
+		query.Set("index", index)
+		query.Set("wait", strconv.Itoa(int(s.WaitTime.Seconds()))+"s")
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func (s *HTTPSource) writeCache(data []byte) error {
+	if s.CacheFile == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.CacheFile), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.CacheFile, data, 0644)
+}
+
+func (s *HTTPSource) client() *http.Client {
+	if s.Client == nil {
+		return http.DefaultClient
+	}
+	return s.Client
+}
@@ -0,0 +1,165 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/config"
+)
+
+type configServer struct {
+	sync.Mutex
+	body        string
+	etag        string
+	failures    int
+	notModified int
+	requests    []*http.Request
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	s.requests = append(s.requests, r)
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if s.etag != "" && r.Header.Get("If-None-Match") == s.etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	w.Header().Set("X-Consul-Index", "42")
+	_, _ = w.Write([]byte(s.body))
+}
+
+func (s *configServer) update(body string, etag string) {
+	s.Lock()
+	defer s.Unlock()
+	s.body = body
+	s.etag = etag
+}
+
+func newTestHTTPSource(url string) *HTTPSource {
+	source := NewHTTPSource(url)
+	source.RetryDelay = time.Millisecond
+	source.PollInterval = 10 * time.Millisecond
+	return source
+}
+
+func TestHTTPSourceLoadWithETag(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := &configServer{body: "armoryURL: http://armory.remote\n", etag: `"v1"`}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	source := newTestHTTPSource(ts.URL + "/build.yaml")
+	config, err := Load(source, &TestConfig{})
+	Expect(err).To(BeNil())
+	Expect(config.(*TestConfig).ArmoryURL).To(Equal("http://armory.remote"))
+	Expect(config.(*TestConfig).OutDir).To(Equal("bin"))
+	Expect(config.GetConfigFilePath()).To(Equal(ts.URL + "/build.yaml"))
+
+	data, err := source.Load()
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal("armoryURL: http://armory.remote\n"))
+	Expect(server.notModified).To(Equal(1))
+}
+
+func TestHTTPSourceRetriesAndFallsBackToCache(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "config")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	server := &configServer{body: "outDir: remote\n", etag: `"v1"`, failures: 2}
+	ts := httptest.NewServer(server)
+
+	source := newTestHTTPSource(ts.URL)
+	source.CacheFile = path.Join(tmpDir, "cache", "build.yaml")
+	data, err := source.Load()
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal("outDir: remote\n"))
+	Expect(server.requests).To(HaveLen(3))
+
+	ts.Close()
+	data, err = newTestHTTPSource(ts.URL).Load()
+	Expect(err).NotTo(BeNil())
+
+	missing := newTestHTTPSource(ts.URL)
+	missing.CacheFile = path.Join(tmpDir, "missing", "build.yaml")
+	_, err = missing.Load()
+	Expect(err).To(MatchError(ContainSubstring("failed to read cache (open " + missing.CacheFile)))
+	Expect(err).To(MatchError(ContainSubstring("failed to fetch config from " + ts.URL)))
+
+	cached := newTestHTTPSource(ts.URL)
+	cached.CacheFile = source.CacheFile
+	data, err = cached.Load()
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal("outDir: remote\n"))
+}
+
+func TestConsulSource(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := &configServer{body: `{"outDir": "consul"}`}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	config, err := Load(NewConsulSource(ts.URL, "app/build"), &TestConfig{})
+	Expect(err).To(BeNil())
+	Expect(config.(*TestConfig).OutDir).To(Equal("consul"))
+	Expect(server.requests[0].URL.Path).To(Equal("/v1/kv/app/build"))
+	Expect(server.requests[0].URL.Query()).To(HaveKey("raw"))
+}
+
+func TestStoreWatchesHTTPSource(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := &configServer{body: "outDir: first\n", etag: `"v1"`}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	store, err := NewStore(newTestHTTPSource(ts.URL), func() Config { return &TestConfig{} })
+	Expect(err).To(BeNil())
+	Expect(store.Get().(*TestConfig).OutDir).To(Equal("first"))
+
+	changes := make(chan string, 10)
+	store.OnChange(func(old Config, new Config) {
+		changes <- old.(*TestConfig).OutDir + "->" + new.(*TestConfig).OutDir
+	})
+
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- store.Watch(stop) }()
+
+	server.update("outDir: second\n", `"v2"`)
+	Eventually(changes).Should(Receive(Equal("first->second")))
+	Expect(store.Get().(*TestConfig).OutDir).To(Equal("second"))
+	Expect(store.Get().(*TestConfig).initialized).To(Equal(true))
+
+	close(stop)
+	Eventually(done).Should(Receive(BeNil()))
+
+	// changed document is fetched by the watch request only
+	server.Lock()
+	defer server.Unlock()
+	loads := 0
+	for _, r := range server.requests {
+		if r.Header.Get("Prefer") == "" {
+			loads++
+		}
+	}
+	Expect(loads).To(Equal(1))
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/pkg/errors"
+	"github.com/smecsia/go-utils/pkg/util/yamledit"
+	"gopkg.in/yaml.v2"
+)
+
+// Override sets value at the path of config document (e.g. platforms[+].os=freebsd)
+type Override struct {
+	Path  string
+	Value string
+}
+
+// OverrideSource applies overrides to the document read from the underlying source
+type OverrideSource struct {
+	Source
+	Overrides []Override
+	// Target is the config the document is read into (values of its string fields are kept as is)
+	Target Config
+}
+
+// OverrideFlag adds repeatable --set flag to command line
+type OverrideFlag struct {
+	Values []string
+}
+
+// NewOverrideSource returns source applying overrides to the document of the source
+// which is read into the target config
+func NewOverrideSource(source Source, target Config, overrides ...Override) *OverrideSource {
+	return &OverrideSource{Source: source, Target: target, Overrides: overrides}
+}
+
+// ParseOverride parses override in form of path=value where path uses yamledit syntax
+// (a.b for nested keys, [0] for list items, [+] to append, "a.b" for keys containing dots)
+func ParseOverride(override string) (Override, error) {
+	parts := strings.SplitN(override, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return Override{}, errors.Errorf("invalid override %q, expected path=value", override)
+	}
+	return Override{Path: strings.TrimSpace(parts[0]), Value: parts[1]}, nil
+}
+
+// ParseOverrides parses list of overrides in form of path=value
+func ParseOverrides(overrides []string) ([]Override, error) {
+	res := make([]Override, 0, len(overrides))
+	for _, override := range overrides {
+		parsed, err := ParseOverride(override)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, parsed)
+	}
+	return res, nil
+}
+
+// ApplyOverrides applies overrides to the raw yaml document in order, values are parsed as yaml
+// unless they are set to string fields of the target (if provided)
+func ApplyOverrides(data []byte, overrides []Override, target interface{}) ([]byte, error) {
+	editor := yamledit.YamlEdit{}
+	for _, override := range overrides {
+		value := override.valueOf()
+		if target != nil && isStringField(reflect.TypeOf(target), editor.ParsePath(override.Path)) {
+			value = override.Value
+		}
+		var err error
+		if data, err = editor.ModifyDocument(data, override.Path, value); err != nil {
+			return nil, errors.Wrapf(err, "failed to apply override %s", override.Path)
+		}
+	}
+	return data, nil
+}
+
+// valueOf returns value parsed as yaml so that numbers, booleans and lists keep their types
+func (o Override) valueOf() interface{} {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(o.Value), &value); err != nil || value == nil {
+		return o.Value
+	}
+	return value
+}
+
+// isStringField returns true if path leads to the field of string kind
+func isStringField(valueType reflect.Type, path []string) bool {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	if len(path) == 0 {
+		return valueType.Kind() == reflect.String
+	}
+	switch valueType.Kind() {
+	case reflect.Struct:
+		for i := 0; i < valueType.NumField(); i++ {
+			fieldType := valueType.Field(i)
+			if fieldType.PkgPath != "" {
+				continue
+			}
+			tagParts := strings.Split(fieldType.Tag.Get(yamlTag), ",")
+			if len(tagParts) > 1 && tagParts[1] == "inline" {
+				if isStringField(fieldType.Type, path) {
+					return true
+				}
+				continue
+			}
+			name := tagParts[0]
+			if name == "" {
+				name = strings.ToLower(fieldType.Name)
+			}
+			if name == path[0] {
+				return isStringField(fieldType.Type, path[1:])
+			}
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return isStringField(valueType.Elem(), path[1:])
+	}
+	return false
+}
+
+// Load reads document from the underlying source and applies overrides
+func (s *OverrideSource) Load() ([]byte, error) {
+	data, err := s.Source.Load()
+	if err != nil {
+		return data, err
+	}
+	return ApplyOverrides(data, s.Overrides, s.Target)
+}
+
+// Mount adds the flag to the command
+func (o *OverrideFlag) Mount(cmd *kingpin.CmdClause) {
+	cmd.Flag("set", "Override config value (e.g. --set platforms[+].os=freebsd)").PlaceHolder("PATH=VALUE").StringsVar(&o.Values)
+}
+
+// Source returns source applying overrides provided via command line to the document read into the target
+func (o *OverrideFlag) Source(source Source, target Config) (Source, error) {
+	overrides, err := ParseOverrides(o.Values)
+	if err != nil {
+		return nil, err
+	}
+	return NewOverrideSource(source, target, overrides...), nil
+}
@@ -0,0 +1,75 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/kingpin"
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/config"
+)
+
+func TestOverrideSource(t *testing.T) {
+	RegisterTestingT(t)
+
+	overrides, err := ParseOverrides([]string{
+		"platforms[+].os=freebsd",
+		"platforms[2].arch=amd64",
+		"platforms[0].arch=arm64",
+		"targets[0].name=deploy",
+		"version=1.10",
+		"outDir=0123",
+	})
+	Expect(err).To(BeNil())
+
+	testConfig := &TestConfig{}
+	cfg, err := Load(NewOverrideSource(NewFileSource("testdata/build.yaml"), testConfig, overrides...), testConfig)
+	Expect(err).To(BeNil())
+	Expect(cfg).To(BeIdenticalTo(testConfig))
+	Expect(testConfig.Platforms).To(Equal([]Platform{
+		{GOOS: "linux", GOARCH: "arm64"},
+		{GOOS: "darwin", GOARCH: "amd64"},
+		{GOOS: "freebsd", GOARCH: "amd64"},
+	}))
+	Expect(testConfig.Targets[0].Name).To(Equal("deploy"))
+	// values of string fields are kept as is
+	Expect(testConfig.Version).To(Equal("1.10"))
+	Expect(testConfig.OutDir).To(Equal("0123"))
+	Expect(testConfig.ArmoryURL).To(Equal("http://armory.local"))
+	Expect(testConfig.GetConfigFilePath()).To(Equal("testdata/build.yaml"))
+}
+
+func TestParseOverride(t *testing.T) {
+	RegisterTestingT(t)
+
+	override, err := ParseOverride(`"a.b".c=x=y`)
+	Expect(err).To(BeNil())
+	Expect(override).To(Equal(Override{Path: `"a.b".c`, Value: "x=y"}))
+
+	data, err := ApplyOverrides([]byte("a.b:\n  d: 1\n"), []Override{override, {Path: "e", Value: "[1, 2]"}}, nil)
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal("a.b:\n  d: 1\n  c: x=y\ne:\n- 1\n- 2\n"))
+
+	_, err = ParseOverride("=value")
+	Expect(err).NotTo(BeNil())
+	_, err = ParseOverride("novalue")
+	Expect(err).NotTo(BeNil())
+}
+
+func TestOverrideFlag(t *testing.T) {
+	RegisterTestingT(t)
+
+	app := kingpin.New("test", "")
+	cmd := app.Command("run", "")
+	flag := OverrideFlag{}
+	flag.Mount(cmd)
+	_, err := app.Parse([]string{"run", "--set", "version=2.0.0", "--set", "outDir=dist"})
+	Expect(err).To(BeNil())
+
+	testConfig := &TestConfig{}
+	source, err := flag.Source(NewFileSource("testdata/build.yaml"), testConfig)
+	Expect(err).To(BeNil())
+	cfg, err := Load(source, testConfig)
+	Expect(err).To(BeNil())
+	Expect(cfg.(*TestConfig).Version).To(Equal("2.0.0"))
+	Expect(cfg.(*TestConfig).OutDir).To(Equal("dist"))
+}
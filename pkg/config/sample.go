@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	descriptionTag = "description"
+	allowedTag     = "allowed"
+	exampleTag     = "example"
+	sampleIndent   = "  "
+	sampleMapKey   = "<name>"
+	maxSampleDepth = 8
+)
+
+// WriteSample writes sample yaml document for the config with all keys set to their defaults
+// and commented with descriptions, env overrides and allowed values
+func WriteSample(w io.Writer, cfg Config) error {
+	var buf bytes.Buffer
+	value := reflect.Indirect(reflect.ValueOf(cfg))
+	writeSampleStruct(&buf, value.Type(), "", false, 0)
+	writeSampleEnvOnly(&buf, value.Type())
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeSampleStruct(buf *bytes.Buffer, structType reflect.Type, indent string, listItem bool, depth int) {
+	// the very first line of the list item is prefixed with the list marker
+	marker := indent
+	if listItem {
+		marker = indent + "- "
+		indent += sampleIndent
+	}
+	for i := 0; i < structType.NumField(); i++ {
+		fieldType := structType.Field(i)
+		name := getYamlFieldName(fieldType)
+		if fieldType.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(fieldType.Name)
+		}
+		for _, comment := range sampleComments(fieldType) {
+			fmt.Fprintf(buf, "%s# %s\n", marker, comment)
+			marker = indent
+		}
+		fmt.Fprintf(buf, "%s%s:", marker, name)
+		marker = indent
+		writeSampleValue(buf, fieldType.Type, sampleValueOf(fieldType), indent, depth)
+	}
+}
+
+func writeSampleValue(buf *bytes.Buffer, valueType reflect.Type, example string, indent string, depth int) {
+	valueType = elemTypeOf(valueType)
+	if depth >= maxSampleDepth || (valueType.Kind() == reflect.Struct && !hasSampleFields(valueType)) {
+		buf.WriteString(" {}\n")
+		return
+	}
+	switch valueType.Kind() {
+	case reflect.Struct:
+		buf.WriteString("\n")
+		writeSampleStruct(buf, valueType, indent+sampleIndent, false, depth+1)
+	case reflect.Slice, reflect.Array:
+		elemType := elemTypeOf(valueType.Elem())
+		buf.WriteString("\n")
+		if elemType.Kind() == reflect.Struct && hasSampleFields(elemType) {
+			writeSampleStruct(buf, elemType, indent+sampleIndent, true, depth+1)
+		} else {
+			fmt.Fprintf(buf, "%s%s-", indent, sampleIndent)
+			writeSampleValue(buf, elemType, example, indent+sampleIndent, depth+1)
+		}
+	case reflect.Map:
+		buf.WriteString("\n")
+		fmt.Fprintf(buf, "%s%s%s:", indent, sampleIndent, sampleMapKey)
+		writeSampleValue(buf, valueType.Elem(), example, indent+sampleIndent, depth+1)
+	default:
+		fmt.Fprintf(buf, " %s\n", sampleScalar(valueType, example))
+	}
+}
+
+func sampleComments(fieldType reflect.StructField) []string {
+	var comments []string
+	if description := fieldType.Tag.Get(descriptionTag); description != "" {
+		comments = append(comments, description)
+	}
+	if env := fieldType.Tag.Get(envTag); env != "" {
+		comments = append(comments, "env: "+env)
+	}
+	if allowed := fieldType.Tag.Get(allowedTag); allowed != "" {
+		comments = append(comments, "allowed: "+strings.Replace(allowed, ",", ", ", -1))
+	}
+	if example := fieldType.Tag.Get(exampleTag); example != "" && example != sampleValueOf(fieldType) {
+		comments = append(comments, "example: "+example)
+	}
+	return comments
+}
+
+func hasSampleFields(structType reflect.Type) bool {
+	for i := 0; i < structType.NumField(); i++ {
+		fieldType := structType.Field(i)
+		if fieldType.PkgPath == "" && getYamlFieldName(fieldType) != "-" {
+			return true
+		}
+	}
+	return false
+}
+
+func elemTypeOf(valueType reflect.Type) reflect.Type {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	return valueType
+}
+
+// writeSampleEnvOnly lists settings which can only be set via env variables
+func writeSampleEnvOnly(buf *bytes.Buffer, structType reflect.Type) {
+	var lines []string
+	for i := 0; i < structType.NumField(); i++ {
+		fieldType := structType.Field(i)
+		env := fieldType.Tag.Get(envTag)
+		if fieldType.PkgPath != "" || getYamlFieldName(fieldType) != "-" || env == "" {
+			continue
+		}
+		line := fmt.Sprintf("#   %s=%s", env, fieldType.Tag.Get(defaultTag))
+		if description := fieldType.Tag.Get(descriptionTag); description != "" {
+			line += " - " + description
+		}
+		if allowed := fieldType.Tag.Get(allowedTag); allowed != "" {
+			line += " (allowed: " + strings.Replace(allowed, ",", ", ", -1) + ")"
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		buf.WriteString("\n# Settings available via env variables only (with defaults):\n")
+		buf.WriteString(strings.Join(lines, "\n") + "\n")
+	}
+}
+
+// sampleValueOf returns default value of the field (example is used only if there is no default
+// so that copied sample doesn't change behavior)
+func sampleValueOf(fieldType reflect.StructField) string {
+	if defaultValue := fieldType.Tag.Get(defaultTag); defaultValue != "" {
+		return defaultValue
+	}
+	if example := fieldType.Tag.Get(exampleTag); example != "" {
+		return example
+	}
+	if allowed := fieldType.Tag.Get(allowedTag); allowed != "" {
+		return strings.Split(allowed, ",")[0]
+	}
+	return ""
+}
+
+func sampleScalar(valueType reflect.Type, example string) string {
+	value := reflect.New(valueType)
+	if example != "" {
+		if err := yaml.Unmarshal([]byte(example), value.Interface()); err != nil {
+			value = reflect.ValueOf(&example)
+		}
+	}
+	out, err := yaml.Marshal(value.Elem().Interface())
+	if err != nil {
+		return `""`
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,88 @@
+package config_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/config"
+	"gopkg.in/yaml.v2"
+)
+
+type SampleItem struct {
+	Name    string   `yaml:"name" description:"Name of the item" example:"first"`
+	Kind    string   `yaml:"kind,omitempty" description:"Kind of the item" allowed:"small,large"`
+	Enabled bool     `yaml:"enabled" example:"true"`
+	Tags    []string `yaml:"tags,omitempty" example:"tag"`
+}
+
+type SampleConfig struct {
+	OutDir  string                `yaml:"outDir,omitempty" env:"OUT_DIR" default:"bin" description:"Output directory"`
+	Retries int64                 `yaml:"retries" env:"RETRIES" default:"3"`
+	Prefix  string                `yaml:"prefix,omitempty" default:"v" description:"Prefix of tags" example:"release-"`
+	Items   []SampleItem          `yaml:"items,omitempty" description:"List of items"`
+	Named   map[string]SampleItem `yaml:"named,omitempty"`
+	Verbose string                `yaml:"-" env:"VERBOSE" default:"false" description:"Verbose output" allowed:"true,false"`
+
+	configFilePath string
+}
+
+func (c *SampleConfig) SetConfigFilePath(path string) {
+	c.configFilePath = path
+}
+
+func (c *SampleConfig) GetConfigFilePath() string {
+	return c.configFilePath
+}
+
+func (c *SampleConfig) Init() error {
+	return nil
+}
+
+func TestWriteSample(t *testing.T) {
+	RegisterTestingT(t)
+
+	var buf bytes.Buffer
+	Expect(WriteSample(&buf, &SampleConfig{})).To(BeNil())
+
+	Expect(buf.String()).To(Equal(`# Output directory
+# env: OUT_DIR
+outDir: bin
+# env: RETRIES
+retries: 3
+# Prefix of tags
+# example: release-
+prefix: v
+# List of items
+items:
+  - # Name of the item
+    name: first
+    # Kind of the item
+    # allowed: small, large
+    kind: small
+    enabled: true
+    tags:
+      - tag
+named:
+  <name>:
+    # Name of the item
+    name: first
+    # Kind of the item
+    # allowed: small, large
+    kind: small
+    enabled: true
+    tags:
+      - tag
+
+# Settings available via env variables only (with defaults):
+#   VERBOSE=false - Verbose output (allowed: true, false)
+`))
+
+	parsed := SampleConfig{}
+	Expect(yaml.Unmarshal(buf.Bytes(), &parsed)).To(BeNil())
+	Expect(parsed.OutDir).To(Equal("bin"))
+	Expect(parsed.Retries).To(Equal(int64(3)))
+	Expect(parsed.Prefix).To(Equal("v"))
+	Expect(parsed.Items).To(Equal([]SampleItem{{Name: "first", Kind: "small", Enabled: true, Tags: []string{"tag"}}}))
+	Expect(parsed.Named).To(HaveKey("<name>"))
+}
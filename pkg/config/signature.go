@@ -0,0 +1,128 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	SignatureFileExt = ".sig"
+)
+
+// VerifiedSource refuses to load config if it doesn't match pinned checksum or signature
+type VerifiedSource struct {
+	Source
+	// Checksum is the pinned hex-encoded sha256 of the config
+	Checksum string
+	// PublicKey verifies signature of the config
+	PublicKey ed25519.PublicKey
+	// SignatureFile is the path to the signature (<config file>.sig by default)
+	SignatureFile string
+}
+
+// NewVerifiedSource returns source verifying signature and/or checksum of the config read from the underlying source
+func NewVerifiedSource(source Source, publicKey ed25519.PublicKey, checksum string) *VerifiedSource {
+	return &VerifiedSource{Source: source, PublicKey: publicKey, Checksum: checksum}
+}
+
+// Load reads config failing if it was tampered with
+func (s *VerifiedSource) Load() ([]byte, error) {
+	data, err := s.Source.Load()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read config %s which must be verified", s.Name())
+	}
+	if s.Checksum != "" {
+		if actual := Checksum(data); !strings.EqualFold(actual, s.Checksum) {
+			return nil, errors.Errorf("checksum of config %s does not match: expected %s, got %s", s.Name(), s.Checksum, actual)
+		}
+	}
+	if s.PublicKey != nil {
+		signatureFile := s.SignatureFile
+		if signatureFile == "" {
+			signatureFile = s.Name() + SignatureFileExt
+		}
+		signature, err := readBase64File(signatureFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read signature of config %s", s.Name())
+		}
+		if !ed25519.Verify(s.PublicKey, data, signature) {
+			return nil, errors.Errorf("signature of config %s is invalid", s.Name())
+		}
+	}
+	return data, nil
+}
+
+// Checksum returns hex-encoded sha256 of the data
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateSigningKeys generates ed25519 key pair and writes base64-encoded keys to the files
+func GenerateSigningKeys(privateKeyFile string, publicKeyFile string) error {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	if err := writeBase64File(privateKeyFile, privateKey, 0600); err != nil {
+		return err
+	}
+	return writeBase64File(publicKeyFile, publicKey, 0644)
+}
+
+// ReadPrivateKey reads base64-encoded ed25519 private key from the file
+func ReadPrivateKey(filePath string) (ed25519.PrivateKey, error) {
+	key, err := readBase64File(filePath)
+	if err != nil {
+		return nil, err
+	} else if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.Errorf("invalid private key in %s", filePath)
+	}
+	return ed25519.PrivateKey(key), nil
+}
+
+// ReadPublicKey reads base64-encoded ed25519 public key from the file
+func ReadPublicKey(filePath string) (ed25519.PublicKey, error) {
+	key, err := readBase64File(filePath)
+	if err != nil {
+		return nil, err
+	} else if len(key) != ed25519.PublicKeySize {
+		return nil, errors.Errorf("invalid public key in %s", filePath)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// SignFile signs config file writing signature to <file>.sig
+func SignFile(filePath string, privateKey ed25519.PrivateKey) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	return writeBase64File(filePath+SignatureFileExt, ed25519.Sign(privateKey, data), 0644)
+}
+
+// VerifyFile verifies signature of config file from <file>.sig
+func VerifyFile(filePath string, publicKey ed25519.PublicKey) error {
+	_, err := NewVerifiedSource(NewFileSource(filePath), publicKey, "").Load()
+	return err
+}
+
+func readBase64File(filePath string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+}
+
+func writeBase64File(filePath string, data []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(filePath, []byte(base64.StdEncoding.EncodeToString(data)+"\n"), perm)
+}
@@ -0,0 +1,56 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/config"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "signature")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	keyFile := path.Join(tmpDir, "build.key")
+	Expect(GenerateSigningKeys(keyFile, keyFile+".pub")).To(BeNil())
+	privateKey, err := ReadPrivateKey(keyFile)
+	Expect(err).To(BeNil())
+	publicKey, err := ReadPublicKey(keyFile + ".pub")
+	Expect(err).To(BeNil())
+
+	cfgFile := path.Join(tmpDir, "build.yaml")
+	writeTestFile(cfgFile, "outDir: bin\nversion: 1.0.0\n")
+	Expect(VerifyFile(cfgFile, publicKey)).NotTo(BeNil())
+	Expect(SignFile(cfgFile, privateKey)).To(BeNil())
+	Expect(VerifyFile(cfgFile, publicKey)).To(BeNil())
+
+	cfg, err := Load(NewVerifiedSource(NewFileSource(cfgFile), publicKey, ""), &TestConfig{})
+	Expect(err).To(BeNil())
+	Expect(cfg.(*TestConfig).Version).To(Equal("1.0.0"))
+
+	writeTestFile(cfgFile, "outDir: bin\nversion: 6.6.6\n")
+	_, err = Load(NewVerifiedSource(NewFileSource(cfgFile), publicKey, ""), &TestConfig{})
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(Equal("signature of config " + cfgFile + " is invalid"))
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	RegisterTestingT(t)
+
+	data, err := ioutil.ReadFile("testdata/build.yaml")
+	Expect(err).To(BeNil())
+	checksum := Checksum(data)
+
+	_, err = Load(NewVerifiedSource(NewFileSource("testdata/build.yaml"), nil, checksum), &TestConfig{})
+	Expect(err).To(BeNil())
+
+	_, err = Load(NewVerifiedSource(NewFileSource("testdata/build.yaml"), nil, Checksum([]byte("other"))), &TestConfig{})
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("checksum of config testdata/build.yaml does not match"))
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"io/ioutil"
+)
+
+// Source provides raw config document (yaml or json)
+type Source interface {
+	// Name returns location of the config (file path, URL etc)
+	Name() string
+	// Load returns contents of the config document
+	Load() ([]byte, error)
+}
+
+// Watcher is implemented by sources able to notify about changes of the document
+type Watcher interface {
+	// Watch blocks until stop is closed calling changed with the new document every time it has changed
+	Watch(stop <-chan struct{}, changed func(data []byte)) error
+}
+
+// FileSource reads config from local file
+type FileSource struct {
+	Path string
+}
+
+// NewFileSource returns source reading config from the file
+func NewFileSource(path string) *FileSource {
+	return &FileSource{Path: path}
+}
+
+// Name returns path to the file
+func (s *FileSource) Name() string {
+	return s.Path
+}
+
+// Load reads contents of the file
+func (s *FileSource) Load() ([]byte, error) {
+	return ioutil.ReadFile(s.Path)
+}
+
+// loadedSource returns document already loaded from another source
+type loadedSource struct {
+	name string
+	data []byte
+}
+
+func (s *loadedSource) Name() string {
+	return s.name
+}
+
+func (s *loadedSource) Load() ([]byte, error) {
+	return s.data, nil
+}
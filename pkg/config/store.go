@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Store keeps the latest config loaded from the source and reloads it on changes
+type Store struct {
+	source    Source
+	newConfig func() Config
+
+	mutex     sync.RWMutex
+	current   Config
+	listeners []func(old Config, new Config)
+}
+
+// NewStore loads config from the source and returns store holding it
+func NewStore(source Source, newConfig func() Config) (*Store, error) {
+	store := &Store{source: source, newConfig: newConfig}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Get returns current config
+func (s *Store) Get() Config {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.current
+}
+
+// OnChange registers listener called every time config is reloaded
+func (s *Store) OnChange(listener func(old Config, new Config)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// Reload reads config from the source and notifies listeners
+func (s *Store) Reload() error {
+	return s.reload(s.source)
+}
+
+func (s *Store) reload(source Source) error {
+	cfg, err := Load(source, s.newConfig())
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	old := s.current
+	if old != nil {
+		if err := recordAudit(AuditSourceReload, old, cfg); err != nil {
+			fmt.Println(fmt.Sprintf("WARN: failed to record config changes of %s: %s", s.source.Name(), err))
+		}
+	}
+	s.current = cfg
+	listeners := append([]func(old Config, new Config){}, s.listeners...)
+	s.mutex.Unlock()
+
+	for _, listener := range listeners {
+		listener(old, cfg)
+	}
+	return nil
+}
+
+// Watch reloads config every time source reports a change until stop is closed
+func (s *Store) Watch(stop <-chan struct{}) error {
+	watcher, ok := s.source.(Watcher)
+	if !ok {
+		return fmt.Errorf("config source %s does not support watching", s.source.Name())
+	}
+	// document reported by the watcher is loaded as is without fetching it once again
+	return watcher.Watch(stop, func(data []byte) {
+		if err := s.reload(&loadedSource{name: s.source.Name(), data: data}); err != nil {
+			fmt.Println(fmt.Sprintf("WARN: failed to reload config from %s: %s", s.source.Name(), err))
+		}
+	})
+}
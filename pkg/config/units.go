@@ -0,0 +1,171 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	. "github.com/smecsia/go-utils/pkg/util"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+)
+
+type convertFn func(value interface{}) (interface{}, error)
+
+// normalizeDurations converts human-friendly durations (e.g. "2d") in raw document to the form yaml understands
+func normalizeDurations(data []byte, rawConfig map[string]interface{}, configType reflect.Type) ([]byte, error) {
+	changed, err := convertDurations(rawConfig, configType, func(value interface{}) (interface{}, error) {
+		duration, err := ParseDuration(fmt.Sprint(value))
+		if err != nil {
+			return value, err
+		}
+		return duration.String(), nil
+	})
+	if err != nil || !changed {
+		return data, err
+	}
+	return yaml.Marshal(rawConfig)
+}
+
+// marshalConfig marshals config to yaml writing durations in human-friendly form
+func marshalConfig(cfg Config) ([]byte, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return data, err
+	}
+	doc := yaml.MapSlice{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return data, err
+	}
+	changed, err := convertDurations(doc, reflect.TypeOf(cfg), func(value interface{}) (interface{}, error) {
+		duration, err := ParseDuration(fmt.Sprint(value))
+		if err != nil {
+			return value, err
+		}
+		return HumanDuration(duration), nil
+	})
+	if err != nil || !changed {
+		return data, err
+	}
+	return yaml.Marshal(doc)
+}
+
+// convertDurations walks raw document along with the config type converting values of duration fields in place
+func convertDurations(raw interface{}, valueType reflect.Type, convert convertFn) (bool, error) {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	changed := false
+	switch valueType.Kind() {
+	case reflect.Struct:
+		for i := 0; i < valueType.NumField(); i++ {
+			fieldType := valueType.Field(i)
+			if fieldType.PkgPath != "" {
+				continue
+			}
+			tagParts := strings.Split(fieldType.Tag.Get(yamlTag), ",")
+			if len(tagParts) > 1 && tagParts[1] == "inline" {
+				fieldChanged, err := convertDurations(raw, fieldType.Type, convert)
+				if err != nil {
+					return changed, err
+				}
+				changed = changed || fieldChanged
+				continue
+			}
+			name := tagParts[0]
+			if name == "-" {
+				continue
+			} else if name == "" {
+				name = strings.ToLower(fieldType.Name)
+			}
+			fieldChanged, err := convertEntry(raw, name, fieldType.Type, convert)
+			if err != nil {
+				return changed, err
+			}
+			changed = changed || fieldChanged
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := raw.([]interface{}); ok {
+			for i := range items {
+				itemChanged, err := convertItem(&items[i], valueType.Elem(), convert)
+				if err != nil {
+					return changed, err
+				}
+				changed = changed || itemChanged
+			}
+		}
+	case reflect.Map:
+		for _, key := range rawKeys(raw) {
+			entryChanged, err := convertEntry(raw, key, valueType.Elem(), convert)
+			if err != nil {
+				return changed, err
+			}
+			changed = changed || entryChanged
+		}
+	}
+	return changed, nil
+}
+
+func convertEntry(raw interface{}, key interface{}, valueType reflect.Type, convert convertFn) (bool, error) {
+	switch doc := raw.(type) {
+	case map[string]interface{}:
+		if value, ok := doc[fmt.Sprint(key)]; ok {
+			changed, err := convertItem(&value, valueType, convert)
+			doc[fmt.Sprint(key)] = value
+			return changed, err
+		}
+	case map[interface{}]interface{}:
+		if value, ok := doc[key]; ok {
+			changed, err := convertItem(&value, valueType, convert)
+			doc[key] = value
+			return changed, err
+		}
+	case yaml.MapSlice:
+		for i := range doc {
+			if doc[i].Key == key {
+				return convertItem(&doc[i].Value, valueType, convert)
+			}
+		}
+	}
+	return false, nil
+}
+
+func convertItem(value *interface{}, valueType reflect.Type, convert convertFn) (bool, error) {
+	if *value == nil {
+		return false, nil
+	}
+	if valueType == durationType {
+		converted, err := convert(*value)
+		if err != nil {
+			return false, err
+		}
+		changed := converted != *value
+		*value = converted
+		return changed, nil
+	}
+	return convertDurations(*value, valueType, convert)
+}
+
+func rawKeys(raw interface{}) []interface{} {
+	var res []interface{}
+	switch doc := raw.(type) {
+	case map[string]interface{}:
+		for key := range doc {
+			res = append(res, key)
+		}
+	case map[interface{}]interface{}:
+		for key := range doc {
+			res = append(res, key)
+		}
+	case yaml.MapSlice:
+		for _, item := range doc {
+			res = append(res, item.Key)
+		}
+	}
+	return res
+}
@@ -0,0 +1,65 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/config"
+	"github.com/smecsia/go-utils/pkg/util"
+)
+
+type Limits struct {
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	Memory  util.ByteSize `yaml:"memory,omitempty"`
+}
+
+type UnitsConfig struct {
+	Timeout  time.Duration `yaml:"timeout,omitempty" env:"TIMEOUT" default:"90s"`
+	CacheTTL time.Duration `yaml:"cacheTTL,omitempty" env:"CACHE_TTL" default:"1d"`
+	MaxSize  util.ByteSize `yaml:"maxSize,omitempty" env:"MAX_SIZE" default:"512Mi"`
+	Limits   []Limits      `yaml:"limits,omitempty"`
+
+	configFilePath string
+}
+
+func (c *UnitsConfig) SetConfigFilePath(path string) {
+	c.configFilePath = path
+}
+
+func (c *UnitsConfig) GetConfigFilePath() string {
+	return c.configFilePath
+}
+
+func (c *UnitsConfig) Init() error {
+	return nil
+}
+
+func TestDurationsAndSizes(t *testing.T) {
+	RegisterTestingT(t)
+
+	defer os.Setenv("MAX_SIZE", "")
+	os.Setenv("MAX_SIZE", "1.5GB")
+
+	cfg, err := Load(bytesSource("timeout: 2d\nlimits:\n- timeout: 1h30m\n  memory: 1Gi\n"), &UnitsConfig{})
+	Expect(err).To(BeNil())
+	config := cfg.(*UnitsConfig)
+	Expect(config.Timeout).To(Equal(48 * time.Hour))
+	Expect(config.CacheTTL).To(Equal(24 * time.Hour))
+	Expect(config.MaxSize).To(Equal(1500 * util.MB))
+	Expect(config.Limits[0].Timeout).To(Equal(90 * time.Minute))
+	Expect(config.Limits[0].Memory).To(Equal(util.GiB))
+
+	tmpDir, err := ioutil.TempDir("", "units")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	configFile := path.Join(tmpDir, "config.yaml")
+	Expect(WriteConfigFile(configFile, config)).To(BeNil())
+	data, err := ioutil.ReadFile(configFile)
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal("timeout: 2d\ncacheTTL: 1d\nmaxSize: 1.5GB\nlimits:\n- timeout: 1h30m\n  memory: 1Gi\n"))
+}
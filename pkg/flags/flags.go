@@ -0,0 +1,283 @@
+package flags
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/smecsia/go-utils/pkg/config"
+)
+
+const (
+	VariantOn  = "on"
+	VariantOff = "off"
+
+	AttrUser     = "user"
+	AttrTeam     = "team"
+	AttrPlatform = "platform"
+
+	OpIn     = "in"
+	OpNotIn  = "notIn"
+	OpPrefix = "prefix"
+
+	buckets = 10000
+)
+
+var (
+	Operators = []string{OpIn, OpNotIn, OpPrefix}
+)
+
+// Attributes describe the subject flags are evaluated for (user, team, platform etc)
+type Attributes map[string]string
+
+// Flags defines set of feature flags by name
+type Flags map[string]Flag
+
+// Flag defines single feature flag
+type Flag struct {
+	Description string    `yaml:"description,omitempty" json:"description,omitempty" description:"What the flag toggles"`
+	Enabled     bool      `yaml:"enabled" json:"enabled" description:"Master switch, default variant is served when disabled"`
+	Default     string    `yaml:"default,omitempty" json:"default,omitempty" description:"Variant served when flag is off" example:"off"`
+	Rollout     *float64  `yaml:"rollout,omitempty" json:"rollout,omitempty" description:"Percentage of subjects the flag is on for" example:"100"`
+	BucketBy    string    `yaml:"bucketBy,omitempty" json:"bucketBy,omitempty" description:"Attribute used to split subjects into rollout buckets" example:"user"`
+	Variants    []Variant `yaml:"variants,omitempty" json:"variants,omitempty" description:"Weighted variants served instead of on/off"`
+	Rules       []Rule    `yaml:"rules,omitempty" json:"rules,omitempty" description:"Targeting rules evaluated in order"`
+}
+
+// Variant defines weighted variant of the flag
+type Variant struct {
+	Name   string  `yaml:"name" json:"name" description:"Name of the variant" example:"on"`
+	Weight float64 `yaml:"weight" json:"weight" description:"Relative weight of the variant" example:"100"`
+}
+
+// Rule defines targeting rule: if attribute matches, variant is served (to rollout percentage of subjects)
+type Rule struct {
+	Attribute string   `yaml:"attribute" json:"attribute" description:"Subject attribute to match" allowed:"user,team,platform"`
+	Operator  string   `yaml:"operator,omitempty" json:"operator,omitempty" description:"How attribute is matched" allowed:"in,notIn,prefix"`
+	Values    []string `yaml:"values" json:"values" description:"Values to match attribute against" example:"release"`
+	Variant   string   `yaml:"variant,omitempty" json:"variant,omitempty" description:"Variant served when rule matches" example:"on"`
+	Rollout   *float64 `yaml:"rollout,omitempty" json:"rollout,omitempty" description:"Percentage of matching subjects the rule applies to" example:"100"`
+}
+
+// Provider is implemented by configs defining feature flags
+type Provider interface {
+	FeatureFlags() Flags
+}
+
+// Evaluator evaluates flags keeping definitions up to date with the config store
+type Evaluator struct {
+	mutex sync.RWMutex
+	flags Flags
+}
+
+// NewEvaluator returns evaluator for the flags
+func NewEvaluator(flags Flags) *Evaluator {
+	return &Evaluator{flags: flags}
+}
+
+// NewStoreEvaluator returns evaluator which picks up flags every time config is reloaded
+func NewStoreEvaluator(store *config.Store) *Evaluator {
+	evaluator := NewEvaluator(flagsOf(store.Get()))
+	store.OnChange(func(old config.Config, new config.Config) {
+		evaluator.Update(flagsOf(new))
+	})
+	return evaluator
+}
+
+// Update replaces flag definitions
+func (e *Evaluator) Update(flags Flags) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.flags = flags
+}
+
+// Variant returns variant of the flag for the subject
+func (e *Evaluator) Variant(name string, attrs Attributes) string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.flags.Variant(name, attrs)
+}
+
+// IsEnabled returns true if flag is on for the subject
+func (e *Evaluator) IsEnabled(name string, attrs Attributes) bool {
+	return e.Variant(name, attrs) == VariantOn
+}
+
+// Variant returns variant of the flag for the subject ("off" if flag is not defined)
+func (f Flags) Variant(name string, attrs Attributes) string {
+	flag, ok := f[name]
+	if !ok {
+		return VariantOff
+	}
+	return flag.Evaluate(name, attrs)
+}
+
+// IsEnabled returns true if flag is on for the subject
+func (f Flags) IsEnabled(name string, attrs Attributes) bool {
+	return f.Variant(name, attrs) == VariantOn
+}
+
+// Validate checks that every flag is consistent (called when config is loaded)
+func (f Flags) Validate() error {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := f[name].Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+	return nil
+}
+
+// Validate checks rollout percentage, weights of variants and targeting rules
+func (f Flag) Validate() error {
+	if err := validateRollout(f.Rollout); err != nil {
+		return err
+	}
+	total := 0.0
+	for _, variant := range f.Variants {
+		if variant.Name == "" {
+			return fmt.Errorf("variant name must not be empty")
+		}
+		if variant.Weight < 0 {
+			return fmt.Errorf("weight of variant %s must not be negative: %v", variant.Name, variant.Weight)
+		}
+		total += variant.Weight
+	}
+	if len(f.Variants) > 0 && total <= 0 {
+		return fmt.Errorf("total weight of variants must be positive")
+	}
+	for i, rule := range f.Rules {
+		if err := rule.Validate(); err != nil {
+			return errors.Wrapf(err, "rules[%d]", i)
+		}
+	}
+	return nil
+}
+
+// Validate checks that rule has attribute, supported operator and valid rollout percentage
+func (r Rule) Validate() error {
+	if r.Attribute == "" {
+		return fmt.Errorf("rule attribute must not be empty")
+	}
+	if r.Operator != "" && !contains(Operators, r.Operator) {
+		return fmt.Errorf("unsupported operator %q, expected one of %v", r.Operator, Operators)
+	}
+	return validateRollout(r.Rollout)
+}
+
+// Evaluate returns variant of the flag for the subject
+func (f Flag) Evaluate(name string, attrs Attributes) string {
+	if !f.Enabled {
+		return f.offVariant()
+	}
+	bucket := f.bucket(name, attrs)
+	for _, rule := range f.Rules {
+		if rule.Matches(attrs) && inRollout(rule.Rollout, bucket) {
+			if rule.Variant != "" {
+				return rule.Variant
+			}
+			return VariantOn
+		}
+	}
+	if len(f.Variants) > 0 {
+		return f.pickVariant(bucket)
+	}
+	if !inRollout(f.Rollout, bucket) {
+		return f.offVariant()
+	}
+	return VariantOn
+}
+
+// Matches returns true if subject attribute matches the rule
+func (r Rule) Matches(attrs Attributes) bool {
+	value, ok := attrs[r.Attribute]
+	switch r.Operator {
+	case OpNotIn:
+		return !ok || !contains(r.Values, value)
+	case OpPrefix:
+		if !ok {
+			return false
+		}
+		for _, prefix := range r.Values {
+			if strings.HasPrefix(value, prefix) {
+				return true
+			}
+		}
+		return false
+	default:
+		return ok && contains(r.Values, value)
+	}
+}
+
+func (f Flag) offVariant() string {
+	if f.Default != "" {
+		return f.Default
+	}
+	return VariantOff
+}
+
+func (f Flag) pickVariant(bucket int) string {
+	total := 0.0
+	for _, variant := range f.Variants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return f.offVariant()
+	}
+	point := float64(bucket) / buckets * total
+	for _, variant := range f.Variants {
+		if point < variant.Weight {
+			return variant.Name
+		}
+		point -= variant.Weight
+	}
+	return f.Variants[len(f.Variants)-1].Name
+}
+
+// bucket deterministically assigns subject to one of the buckets based on flag name and subject key
+func (f Flag) bucket(name string, attrs Attributes) int {
+	bucketBy := f.BucketBy
+	if bucketBy == "" {
+		bucketBy = AttrUser
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name + "/" + attrs[bucketBy]))
+	return int(hash.Sum32() % buckets)
+}
+
+func inRollout(rollout *float64, bucket int) bool {
+	if rollout == nil {
+		return true
+	}
+	return float64(bucket) < *rollout*buckets/100
+}
+
+func validateRollout(rollout *float64) error {
+	if rollout != nil && (*rollout < 0 || *rollout > 100) {
+		return fmt.Errorf("rollout must be between 0 and 100: %v", *rollout)
+	}
+	return nil
+}
+
+func flagsOf(cfg config.Config) Flags {
+	if provider, ok := cfg.(Provider); ok {
+		return provider.FeatureFlags()
+	}
+	return Flags{}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
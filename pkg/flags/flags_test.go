@@ -0,0 +1,129 @@
+package flags_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/smecsia/go-utils/pkg/config"
+	. "github.com/smecsia/go-utils/pkg/flags"
+)
+
+type FlagsConfig struct {
+	Flags Flags `yaml:"flags,omitempty"`
+
+	configFilePath string
+}
+
+func (c *FlagsConfig) SetConfigFilePath(path string) {
+	c.configFilePath = path
+}
+
+func (c *FlagsConfig) GetConfigFilePath() string {
+	return c.configFilePath
+}
+
+func (c *FlagsConfig) Init() error {
+	return nil
+}
+
+func (c *FlagsConfig) FeatureFlags() Flags {
+	return c.Flags
+}
+
+func loadFlags(filePath string) Flags {
+	cfg, err := config.Load(config.NewFileSource(filePath), &FlagsConfig{})
+	Expect(err).To(BeNil())
+	return cfg.(*FlagsConfig).Flags
+}
+
+func TestPercentageRolloutIsDeterministic(t *testing.T) {
+	RegisterTestingT(t)
+
+	flags := loadFlags("testdata/flags.yaml")
+	enabled := 0
+	for i := 0; i < 10000; i++ {
+		user := Attributes{AttrUser: fmt.Sprintf("user-%d", i)}
+		if flags.IsEnabled("newBundler", user) {
+			enabled++
+		}
+		Expect(flags.IsEnabled("newBundler", user)).To(Equal(flags.IsEnabled("newBundler", user)))
+	}
+	Expect(enabled).To(BeNumerically("~", 2500, 200))
+}
+
+func TestRulesAndVariants(t *testing.T) {
+	RegisterTestingT(t)
+
+	flags := loadFlags("testdata/flags.yaml")
+	Expect(flags.IsEnabled("skipTests", Attributes{AttrPlatform: "windows-amd64"})).To(BeTrue())
+	Expect(flags.IsEnabled("skipTests", Attributes{AttrPlatform: "linux-amd64"})).To(BeFalse())
+	Expect(flags.IsEnabled("disabled", Attributes{})).To(BeFalse())
+	Expect(flags.IsEnabled("missing", Attributes{})).To(BeFalse())
+	Expect(flags.Variant("compressor", Attributes{AttrTeam: "release", AttrUser: "a"})).To(Equal("xz"))
+
+	variants := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		variants[flags.Variant("compressor", Attributes{AttrUser: fmt.Sprintf("user-%d", i)})]++
+	}
+	Expect(variants).To(HaveLen(2))
+	Expect(variants["gzip"]).To(BeNumerically("~", 500, 100))
+}
+
+func TestRuleOperators(t *testing.T) {
+	RegisterTestingT(t)
+
+	rule := Rule{Attribute: AttrTeam, Operator: OpPrefix, Values: []string{"release-"}}
+	Expect(rule.Matches(Attributes{AttrTeam: "release-eng"})).To(BeTrue())
+	Expect(rule.Matches(Attributes{AttrTeam: "platform"})).To(BeFalse())
+
+	rule = Rule{Attribute: AttrTeam, Operator: OpNotIn, Values: []string{"platform"}}
+	Expect(rule.Matches(Attributes{AttrTeam: "release"})).To(BeTrue())
+	Expect(rule.Matches(Attributes{AttrTeam: "platform"})).To(BeFalse())
+}
+
+func TestValidateFlags(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "flags")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	configFile := path.Join(tmpDir, "build.yaml")
+	for flag, expected := range map[string]string{
+		"rollout: 150":                                  "flags: feature: rollout must be between 0 and 100: 150",
+		"variants: [{name: a, weight: -1}]":             "flags: feature: weight of variant a must not be negative: -1",
+		"variants: [{name: a, weight: 0}]":              "flags: feature: total weight of variants must be positive",
+		"rules: [{attribute: team, operator: matches}]": `flags: feature: rules[0]: unsupported operator "matches", expected one of [in notIn prefix]`,
+		"rules: [{attribute: team, rollout: -5}]":       "flags: feature: rules[0]: rollout must be between 0 and 100: -5",
+		"rules: [{values: [release], variant: on}]":     "flags: feature: rules[0]: rule attribute must not be empty",
+	} {
+		Expect(ioutil.WriteFile(configFile, []byte("flags:\n  feature:\n    enabled: true\n    "+flag+"\n"), 0644)).To(BeNil())
+		_, err := config.Load(config.NewFileSource(configFile), &FlagsConfig{})
+		Expect(err).To(MatchError(expected))
+	}
+	Expect(loadFlags("testdata/flags.yaml").Validate()).To(BeNil())
+}
+
+func TestStoreEvaluatorPicksUpReloadedFlags(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "flags")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	configFile := path.Join(tmpDir, "build.yaml")
+	Expect(ioutil.WriteFile(configFile, []byte("flags:\n  feature:\n    enabled: false\n"), 0644)).To(BeNil())
+
+	store, err := config.NewStore(config.NewFileSource(configFile), func() config.Config { return &FlagsConfig{} })
+	Expect(err).To(BeNil())
+	evaluator := NewStoreEvaluator(store)
+	Expect(evaluator.IsEnabled("feature", Attributes{})).To(BeFalse())
+
+	Expect(ioutil.WriteFile(configFile, []byte("flags:\n  feature:\n    enabled: true\n"), 0644)).To(BeNil())
+	Expect(store.Reload()).To(BeNil())
+	Expect(evaluator.IsEnabled("feature", Attributes{})).To(BeTrue())
+}
@@ -8,6 +8,7 @@ import (
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 )
 
@@ -15,10 +16,31 @@ type Git interface {
 	IsWorkTreeClean() (bool, string, error)
 	HashShort() (string, error)
 	Hash() (string, error)
+	CurrentBranch() (string, error)
+	Tags() ([]Tag, error)
+	ResolveRevision(rev string) (string, error)
+	Log(from string, until func(commit Commit) bool) ([]Commit, error)
+	Add(paths ...string) error
 	CommitAndPush(msg string) error
+	CreateTag(name string, message string) error
+	PushTag(name string) error
 	Root() string
 }
 
+// Commit describes commit of the repository
+type Commit struct {
+	Hash    string
+	Message string
+	Author  string
+	When    time.Time
+}
+
+// Tag describes tag along with the commit it points to
+type Tag struct {
+	Name string
+	Hash string
+}
+
 type GitImpl struct {
 	RootPath string
 	Author   string
@@ -96,6 +118,156 @@ func (ctx *GitImpl) Hash() (string, error) {
 	return plumbingHash.String(), nil
 }
 
+// CurrentBranch returns name of the checked out branch (empty if HEAD is detached)
+func (ctx *GitImpl) CurrentBranch() (string, error) {
+	r, _, err := ctx.gitWorkTree()
+	if err != nil {
+		return "", err
+	}
+	head, err := r.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to resolve HEAD of repository")
+	}
+	if !head.Name().IsBranch() {
+		return "", nil
+	}
+	return head.Name().Short(), nil
+}
+
+// Tags returns all tags of repository (annotated tags are resolved to their commits)
+func (ctx *GitImpl) Tags() ([]Tag, error) {
+	r, _, err := ctx.gitWorkTree()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.Tags()
+	if err != nil {
+		return nil, err
+	}
+	var res []Tag
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		if tag, err := r.TagObject(hash); err == nil {
+			commit, err := tag.Commit()
+			if err != nil {
+				return nil
+			}
+			hash = commit.Hash
+		}
+		res = append(res, Tag{Name: ref.Name().Short(), Hash: hash.String()})
+		return nil
+	})
+	return res, err
+}
+
+// ResolveRevision returns hash of the commit revision (e.g. tag, branch or HEAD~2) points to
+func (ctx *GitImpl) ResolveRevision(rev string) (string, error) {
+	r, _, err := ctx.gitWorkTree()
+	if err != nil {
+		return "", err
+	}
+	hash, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to resolve revision %s", rev)
+	}
+	return hash.String(), nil
+}
+
+// Log returns commits reachable from revision (HEAD if empty) newest first excluding commits for which
+// until returns true along with all commits reachable from them (like git log <from> --not <commits>),
+// until can be nil to return the whole history
+func (ctx *GitImpl) Log(from string, until func(commit Commit) bool) ([]Commit, error) {
+	r, _, err := ctx.gitWorkTree()
+	if err != nil {
+		return nil, err
+	}
+	var start *plumbing.Hash
+	if from != "" {
+		if start, err = r.ResolveRevision(plumbing.Revision(from)); err != nil {
+			return nil, errors.Wrapf(err, "unable to resolve revision %s", from)
+		}
+	} else {
+		head, err := r.Head()
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read git log")
+		}
+		hash := head.Hash()
+		start = &hash
+	}
+	startCommit, err := r.CommitObject(*start)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read git log")
+	}
+
+	// walk history up to boundary commits (ones until returns true for)
+	var included, boundaries []*object.Commit
+	visited := map[plumbing.Hash]bool{startCommit.Hash: true}
+	queue := []*object.Commit{startCommit}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if until != nil && until(toCommit(c)) {
+			boundaries = append(boundaries, c)
+			continue
+		}
+		included = append(included, c)
+		if err := walkParents(c, visited, func(parent *object.Commit) { queue = append(queue, parent) }); err != nil {
+			return nil, err
+		}
+	}
+
+	// commits reachable from boundaries are excluded even if they are reachable via other parents (merges)
+	excluded := make(map[plumbing.Hash]bool)
+	for len(boundaries) > 0 {
+		c := boundaries[0]
+		boundaries = boundaries[1:]
+		excluded[c.Hash] = true
+		if err := walkParents(c, excluded, func(parent *object.Commit) { boundaries = append(boundaries, parent) }); err != nil {
+			return nil, err
+		}
+	}
+
+	var res []Commit
+	sort.SliceStable(included, func(i, j int) bool {
+		return included[i].Committer.When.After(included[j].Committer.When)
+	})
+	for _, c := range included {
+		if !excluded[c.Hash] {
+			res = append(res, toCommit(c))
+		}
+	}
+	return res, nil
+}
+
+// walkParents calls visit for parents of the commit which are not seen yet marking them as seen
+func walkParents(c *object.Commit, seen map[plumbing.Hash]bool, visit func(parent *object.Commit)) error {
+	return c.Parents().ForEach(func(parent *object.Commit) error {
+		if !seen[parent.Hash] {
+			seen[parent.Hash] = true
+			visit(parent)
+		}
+		return nil
+	})
+}
+
+func toCommit(c *object.Commit) Commit {
+	return Commit{Hash: c.Hash.String(), Message: c.Message, Author: c.Author.Name, When: c.Author.When}
+}
+
+// Add adds files (relative to git root) to the index so that new files are included into the next commit
+func (ctx *GitImpl) Add(paths ...string) error {
+	_, wt, err := ctx.gitWorkTree()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := wt.Add(path); err != nil {
+			return errors.Wrapf(err, "unable to add %s", path)
+		}
+	}
+	return nil
+}
+
 // CommitAndPush makes commit and pushes to master
 func (ctx *GitImpl) CommitAndPush(msg string) error {
 	r, wt, err := ctx.gitWorkTree()
@@ -124,6 +296,46 @@ func (ctx *GitImpl) CommitAndPush(msg string) error {
 	return nil
 }
 
+// CreateTag creates tag pointing to HEAD (annotated if message is not empty, lightweight otherwise)
+func (ctx *GitImpl) CreateTag(name string, message string) error {
+	r, err := git.PlainOpen(ctx.RootPath)
+	if err != nil {
+		return err
+	}
+	head, err := r.Head()
+	if err != nil {
+		return err
+	}
+	var opts *git.CreateTagOptions
+	if message != "" {
+		opts = &git.CreateTagOptions{
+			Message: message,
+			Tagger:  &object.Signature{Name: ctx.Author, When: time.Now()},
+		}
+	}
+	if _, err := r.CreateTag(name, head.Hash(), opts); err != nil {
+		return errors.Wrapf(err, "unable to create tag %s", name)
+	}
+	return nil
+}
+
+// PushTag pushes tag to the remote
+func (ctx *GitImpl) PushTag(name string) error {
+	r, err := git.PlainOpen(ctx.RootPath)
+	if err != nil {
+		return err
+	}
+	ref := plumbing.NewTagReferenceName(name)
+	pushOpts := git.PushOptions{
+		RemoteName: ctx.Remote,
+		RefSpecs:   []config.RefSpec{config.RefSpec(ref + ":" + ref)},
+	}
+	if err := r.Push(&pushOpts); err != nil && err != git.NoErrAlreadyUpToDate {
+		return errors.Wrapf(err, "unable to push tag %s", name)
+	}
+	return nil
+}
+
 func (ctx *GitImpl) gitWorkTree() (*git.Repository, *git.Worktree, error) {
 	r, err := git.PlainOpen(ctx.RootPath)
 	if err != nil {
@@ -1,6 +1,9 @@
 package mock
 
-import "github.com/stretchr/testify/mock"
+import (
+	"github.com/smecsia/go-utils/pkg/git"
+	"github.com/stretchr/testify/mock"
+)
 
 type GitMock struct {
 	mock.Mock
@@ -11,6 +14,16 @@ func (m *GitMock) CommitAndPush(msg string) error {
 	return args.Error(0)
 }
 
+func (m *GitMock) CreateTag(name string, message string) error {
+	args := m.Called(name, message)
+	return args.Error(0)
+}
+
+func (m *GitMock) PushTag(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
 func (m *GitMock) Root() string {
 	args := m.Called()
 	return args.Get(0).(string)
@@ -21,6 +34,11 @@ func (m *GitMock) Hash() (string, error) {
 	return args.Get(0).(string), args.Error(1)
 }
 
+func (m *GitMock) CurrentBranch() (string, error) {
+	args := m.Called()
+	return args.Get(0).(string), args.Error(1)
+}
+
 func (m *GitMock) HashShort() (string, error) {
 	args := m.Called()
 	return args.Get(0).(string), args.Error(1)
@@ -30,3 +48,23 @@ func (m *GitMock) IsWorkTreeClean() (bool, string, error) {
 	args := m.Called()
 	return args.Get(0).(bool), args.Get(1).(string), args.Error(2)
 }
+
+func (m *GitMock) Tags() ([]git.Tag, error) {
+	args := m.Called()
+	return args.Get(0).([]git.Tag), args.Error(1)
+}
+
+func (m *GitMock) ResolveRevision(rev string) (string, error) {
+	args := m.Called(rev)
+	return args.Get(0).(string), args.Error(1)
+}
+
+func (m *GitMock) Log(from string, until func(commit git.Commit) bool) ([]git.Commit, error) {
+	args := m.Called(from, until)
+	return args.Get(0).([]git.Commit), args.Error(1)
+}
+
+func (m *GitMock) Add(paths ...string) error {
+	args := m.Called(paths)
+	return args.Error(0)
+}
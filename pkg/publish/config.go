@@ -0,0 +1,124 @@
+package publish
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultPath = "{{.Version}}"
+)
+
+// Config defines repository artifacts are published to
+type Config struct {
+	Type       string        `yaml:"type,omitempty" description:"Type of the repository (publishing is disabled if not set)" allowed:"local,http,s3"`
+	Path       string        `yaml:"path,omitempty" description:"Template of the path artifacts are published under ({{.Version}} by default)" example:"releases/{{.Version}}"`
+	Retries    *int64        `yaml:"retries,omitempty" description:"Number of retries of failed requests (3 by default, 0 disables retries)" example:"5"`
+	RetryDelay time.Duration `yaml:"retryDelay,omitempty" description:"Delay between retries (1s by default)" example:"5s"`
+	Overwrite  bool          `yaml:"overwrite,omitempty" description:"Overwrite artifacts already published with different content" example:"true"`
+
+	Local LocalConfig `yaml:"local,omitempty" description:"Local directory or mounted file share"`
+	HTTP  HTTPConfig  `yaml:"http,omitempty" description:"Generic HTTP repository accepting PUT requests (Artifactory, Nexus)"`
+	S3    S3Config    `yaml:"s3,omitempty" description:"S3-compatible object storage"`
+}
+
+// LocalConfig defines directory artifacts are copied to
+type LocalConfig struct {
+	Dir string `yaml:"dir,omitempty" description:"Directory artifacts are copied to" example:"/mnt/releases/app"`
+}
+
+// HTTPConfig defines HTTP repository
+type HTTPConfig struct {
+	URL     string        `yaml:"url,omitempty" description:"Base URL artifacts are uploaded under" example:"https://artifactory.example.com/artifactory/generic-local/app"`
+	Timeout time.Duration `yaml:"timeout,omitempty" description:"Timeout of every request (5m by default)" example:"10m"`
+}
+
+// S3Config defines bucket of S3-compatible object storage
+type S3Config struct {
+	Endpoint  string        `yaml:"endpoint,omitempty" description:"Endpoint of the storage (AWS S3 of the region by default)" example:"https://minio.example.com:9000"`
+	Region    string        `yaml:"region,omitempty" description:"Region of the bucket" example:"us-east-1"`
+	Bucket    string        `yaml:"bucket,omitempty" description:"Bucket artifacts are uploaded to" example:"releases"`
+	Prefix    string        `yaml:"prefix,omitempty" description:"Prefix of keys of all artifacts" example:"app"`
+	PathStyle bool          `yaml:"pathStyle,omitempty" description:"Use path-style URLs (endpoint/bucket/key) required by MinIO and alike" example:"true"`
+	Timeout   time.Duration `yaml:"timeout,omitempty" description:"Timeout of every request (5m by default)" example:"10m"`
+}
+
+// Credentials are secrets of the repository (never stored in config file)
+type Credentials struct {
+	Username     string
+	Password     string
+	Token        string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// IsEnabled returns true if repository is configured
+func (c *Config) IsEnabled() bool {
+	return c.Type != ""
+}
+
+// Validate checks that settings required by the type of repository are set
+func (c *Config) Validate() error {
+	switch c.Type {
+	case "":
+		return nil
+	case TypeLocal:
+		if c.Local.Dir == "" {
+			return fmt.Errorf("publish.local.dir is required")
+		}
+	case TypeHTTP:
+		if c.HTTP.URL == "" {
+			return fmt.Errorf("publish.http.url is required")
+		}
+	case TypeS3:
+		if c.S3.Bucket == "" || c.S3.Region == "" {
+			return fmt.Errorf("publish.s3.bucket and publish.s3.region are required")
+		}
+	default:
+		return fmt.Errorf("unsupported publish type %q, expected one of %v", c.Type, Types)
+	}
+	if c.Retries != nil && *c.Retries < 0 {
+		return fmt.Errorf("publish.retries must not be negative: %d", *c.Retries)
+	}
+	return nil
+}
+
+// Options returns options of publishing
+func (c *Config) Options() Options {
+	res := Options{Retries: DefaultRetries, RetryDelay: DefaultRetryDelay, Overwrite: c.Overwrite}
+	if c.Retries != nil {
+		res.Retries = int(*c.Retries)
+	}
+	if c.RetryDelay > 0 {
+		res.RetryDelay = c.RetryDelay
+	}
+	return res
+}
+
+// New returns publisher of the configured repository
+func New(c Config, credentials Credentials) (Publisher, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	switch c.Type {
+	case TypeLocal:
+		return NewLocalPublisher(c.Local.Dir), nil
+	case TypeHTTP:
+		res := NewHTTPPublisher(c.HTTP.URL)
+		res.Username, res.Password, res.Token = credentials.Username, credentials.Password, credentials.Token
+		if c.HTTP.Timeout > 0 {
+			res.Timeout = c.HTTP.Timeout
+		}
+		return res, nil
+	case TypeS3:
+		res := NewS3Publisher(c.S3.Endpoint, c.S3.Region, c.S3.Bucket)
+		res.Prefix, res.PathStyle = c.S3.Prefix, c.S3.PathStyle
+		res.AccessKey, res.SecretKey, res.SessionToken = credentials.AccessKey, credentials.SecretKey, credentials.SessionToken
+		if c.S3.Timeout > 0 {
+			res.Timeout = c.S3.Timeout
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("publishing is not configured")
+}
@@ -0,0 +1,161 @@
+package publish
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	headerChecksumSHA256 = "X-Checksum-Sha256"
+)
+
+// HTTPPublisher uploads artifacts with HTTP PUT requests (Artifactory, Nexus raw repositories and alike),
+// checksum is sent in X-Checksum-Sha256 header
+type HTTPPublisher struct {
+	URL      string
+	Username string
+	Password string
+	Token    string
+	Header   http.Header
+	Timeout  time.Duration
+	Client   *http.Client
+}
+
+// NewHTTPPublisher returns publisher uploading artifacts under the base URL
+func NewHTTPPublisher(baseURL string) *HTTPPublisher {
+	return &HTTPPublisher{
+		URL:     strings.TrimSuffix(baseURL, "/"),
+		Header:  http.Header{},
+		Timeout: 5 * time.Minute,
+		Client:  http.DefaultClient,
+	}
+}
+
+func (p *HTTPPublisher) String() string {
+	return p.URL
+}
+
+// Stat sends HEAD request taking checksum from X-Checksum-Sha256 header,
+// the artifact is downloaded to calculate checksum if the header is missing
+func (p *HTTPPublisher) Stat(name string) (Object, error) {
+	resp, err := p.do(http.MethodHead, name, nil, 0, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return Object{}, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return Object{}, statusError(http.MethodHead, p.objectURL(name), resp)
+	}
+	if checksum := resp.Header.Get(headerChecksumSHA256); checksum != "" {
+		return Object{Exists: true, SHA256: strings.ToLower(checksum)}, nil
+	}
+	resp, err = p.do(http.MethodGet, name, nil, 0, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Object{}, statusError(http.MethodGet, p.objectURL(name), resp)
+	}
+	checksum, err := readerChecksum(resp.Body)
+	if err != nil {
+		return Object{}, Temporary(errors.Wrapf(err, "failed to download %s", p.objectURL(name)))
+	}
+	return Object{Exists: true, SHA256: checksum}, nil
+}
+
+// Put uploads file with PUT request
+func (p *HTTPPublisher) Put(name string, file string, checksum string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set(headerChecksumSHA256, checksum)
+	header.Set("Content-Type", "application/octet-stream")
+	resp, err := p.do(http.MethodPut, name, f, info.Size(), header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return statusError(http.MethodPut, p.objectURL(name), resp)
+	}
+	return nil
+}
+
+func (p *HTTPPublisher) do(method string, name string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, p.objectURL(name), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	for _, h := range []http.Header{p.Header, header} {
+		for key, values := range h {
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+	}
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	} else if p.Username != "" {
+		req.SetBasicAuth(p.Username, p.Password)
+	}
+	return doRequest(p.Client, req, p.Timeout)
+}
+
+func (p *HTTPPublisher) objectURL(name string) string {
+	return p.URL + "/" + escapePath(name)
+}
+
+// doRequest sends request treating transport errors as temporary
+func doRequest(client *http.Client, req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if timeout > 0 {
+		timeoutClient := *client
+		timeoutClient.Timeout = timeout
+		client = &timeoutClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, Temporary(errors.Wrapf(err, "%s %s failed", req.Method, req.URL))
+	}
+	return resp, nil
+}
+
+// statusError returns error of unexpected response status, server errors and throttling are temporary
+func statusError(method string, url string, resp *http.Response) error {
+	message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	err := fmt.Errorf("%s %s failed: unexpected status %s %s", method, url, resp.Status, strings.TrimSpace(string(message)))
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return Temporary(err)
+	}
+	return err
+}
+
+// escapePath escapes every segment of slash-separated path
+func escapePath(name string) string {
+	segments := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,82 @@
+package publish
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LocalPublisher copies artifacts to the local directory (e.g. mounted file share)
+type LocalPublisher struct {
+	Dir string
+}
+
+// NewLocalPublisher returns publisher copying artifacts to the directory
+func NewLocalPublisher(dir string) *LocalPublisher {
+	return &LocalPublisher{Dir: dir}
+}
+
+func (p *LocalPublisher) String() string {
+	return p.Dir
+}
+
+// Stat returns checksum of the file if it exists
+func (p *LocalPublisher) Stat(name string) (Object, error) {
+	path, err := p.path(name)
+	if err != nil {
+		return Object{}, err
+	}
+	checksum, err := FileChecksum(path)
+	if os.IsNotExist(err) {
+		return Object{}, nil
+	} else if err != nil {
+		return Object{}, err
+	}
+	return Object{Exists: true, SHA256: checksum}, nil
+}
+
+// Put copies file to the temporary file next to destination and renames it so that
+// partially copied artifacts are never visible
+func (p *LocalPublisher) Put(name string, file string, checksum string) error {
+	path, err := p.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	src, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// path returns path of the artifact making sure it is inside of the directory
+func (p *LocalPublisher) path(name string) (string, error) {
+	path := filepath.Join(p.Dir, filepath.FromSlash(name))
+	if rel, err := filepath.Rel(p.Dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("artifact name %q is outside of %s", name, p.Dir)
+	}
+	return path, nil
+}
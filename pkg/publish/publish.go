@@ -0,0 +1,169 @@
+package publish
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	TypeLocal = "local"
+	TypeHTTP  = "http"
+	TypeS3    = "s3"
+
+	StatusUploaded = "uploaded"
+	StatusSkipped  = "skipped"
+	StatusFailed   = "failed"
+
+	DefaultRetries    = 3
+	DefaultRetryDelay = time.Second
+)
+
+var (
+	Types = []string{TypeLocal, TypeHTTP, TypeS3}
+)
+
+// Publisher uploads artifacts to the repository
+type Publisher interface {
+	// Stat returns object published under the name (slash-separated path relative to the repository)
+	Stat(name string) (Object, error)
+	// Put uploads file under the name, checksum is hex-encoded SHA-256 of the file
+	Put(name string, file string, checksum string) error
+	// String returns location of the repository
+	String() string
+}
+
+// Object describes published artifact
+type Object struct {
+	Exists bool
+	SHA256 string
+}
+
+// Artifact is the local file published under the name (slash-separated path relative to the repository)
+type Artifact struct {
+	File string
+	Name string
+}
+
+// Result describes outcome of publishing the artifact
+type Result struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	SHA256 string `json:"sha256,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Options control retries and overwriting of artifacts
+type Options struct {
+	Retries    int
+	RetryDelay time.Duration
+	Overwrite  bool
+}
+
+// temporaryError is the error of request which may succeed if retried
+type temporaryError struct {
+	error
+}
+
+func (e temporaryError) Temporary() bool {
+	return true
+}
+
+// Temporary marks error as temporary so that the request is retried
+func Temporary(err error) error {
+	if err == nil {
+		return nil
+	}
+	return temporaryError{err}
+}
+
+// IsTemporary returns true if the request failed with the error may succeed if retried
+func IsTemporary(err error) bool {
+	temporary, ok := errors.Cause(err).(interface{ Temporary() bool })
+	return ok && temporary.Temporary()
+}
+
+// Publish uploads artifacts one by one skipping ones already published with the same checksum, artifacts
+// published with different content are overwritten only if Overwrite is set. Requests failed with temporary
+// errors are retried
+func Publish(publisher Publisher, artifacts []Artifact, opts Options) ([]Result, error) {
+	var res []Result
+	for _, artifact := range artifacts {
+		result, err := publishArtifact(publisher, artifact, opts)
+		if err != nil {
+			result.Status, result.Error = StatusFailed, err.Error()
+			return append(res, result), errors.Wrapf(err, "failed to publish %s to %s", artifact.Name, publisher)
+		}
+		res = append(res, result)
+	}
+	return res, nil
+}
+
+func publishArtifact(publisher Publisher, artifact Artifact, opts Options) (Result, error) {
+	res := Result{Name: artifact.Name, File: artifact.File}
+	checksum, err := FileChecksum(artifact.File)
+	if err != nil {
+		return res, err
+	}
+	res.SHA256 = checksum
+	var object Object
+	if err := retry(opts, func() (err error) {
+		object, err = publisher.Stat(artifact.Name)
+		return err
+	}); err != nil {
+		return res, err
+	}
+	if object.Exists && object.SHA256 == checksum {
+		res.Status = StatusSkipped
+		return res, nil
+	}
+	if object.Exists && !opts.Overwrite {
+		return res, fmt.Errorf("artifact is already published with different checksum %s", object.SHA256)
+	}
+	if err := retry(opts, func() error {
+		return publisher.Put(artifact.Name, artifact.File, checksum)
+	}); err != nil {
+		return res, err
+	}
+	res.Status = StatusUploaded
+	return res, nil
+}
+
+// retry calls action until it succeeds, fails with permanent error or retries are exhausted
+func retry(opts Options, action func() error) error {
+	var err error
+	for attempt := 0; attempt <= opts.Retries; attempt++ {
+		if attempt > 0 {
+			fmt.Println(fmt.Sprintf("WARN: retrying (%d/%d) after error: %s", attempt, opts.Retries, err))
+			time.Sleep(opts.RetryDelay)
+		}
+		if err = action(); err == nil || !IsTemporary(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// FileChecksum returns hex-encoded SHA-256 of the file
+func FileChecksum(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return readerChecksum(f)
+}
+
+func readerChecksum(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
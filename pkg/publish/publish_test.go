@@ -0,0 +1,183 @@
+package publish_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/publish"
+)
+
+// repositoryServer stores uploaded artifacts in memory like Artifactory or Nexus
+type repositoryServer struct {
+	sync.Mutex
+	objects   map[string][]byte
+	checksums bool
+	failures  int
+	requests  []string
+}
+
+func newRepositoryServer() *repositoryServer {
+	return &repositoryServer{objects: make(map[string][]byte), checksums: true}
+}
+
+func (s *repositoryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	if user, password, ok := r.BasicAuth(); !ok || user != "deployer" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := ioutil.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Checksum-Sha256") != hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.objects[r.URL.Path] = body
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodHead, http.MethodGet:
+		body, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if s.checksums {
+			sum := sha256.Sum256(body)
+			w.Header().Set("X-Checksum-Sha256", hex.EncodeToString(sum[:]))
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(body)
+		}
+	}
+}
+
+func writeArtifacts(dir string, files map[string]string) []Artifact {
+	var res []Artifact
+	for _, name := range []string{"app-linux-amd64.tar.gz", "SHA256SUMS"} {
+		file := path.Join(dir, name)
+		Expect(ioutil.WriteFile(file, []byte(files[name]), 0644)).To(BeNil())
+		res = append(res, Artifact{File: file, Name: "1.0.0/" + name})
+	}
+	return res
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "publish")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	server := newRepositoryServer()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	artifacts := writeArtifacts(tmpDir, map[string]string{"app-linux-amd64.tar.gz": "archive", "SHA256SUMS": "sums"})
+
+	publisher := NewHTTPPublisher(httpServer.URL + "/generic-local/")
+	publisher.Username, publisher.Password = "deployer", "secret"
+	opts := Options{Retries: 2, RetryDelay: time.Millisecond}
+	server.failures = 2
+	results, err := Publish(publisher, artifacts, opts)
+	Expect(err).To(BeNil())
+	Expect(results).To(HaveLen(2))
+	Expect(results[0].Status).To(Equal(StatusUploaded))
+	Expect(results[0].Name).To(Equal("1.0.0/app-linux-amd64.tar.gz"))
+	Expect(string(server.objects["/generic-local/1.0.0/app-linux-amd64.tar.gz"])).To(Equal("archive"))
+
+	server.requests, server.checksums = nil, false
+	results, err = Publish(publisher, artifacts, opts)
+	Expect(err).To(BeNil())
+	Expect(results[0].Status).To(Equal(StatusSkipped))
+	Expect(results[1].Status).To(Equal(StatusSkipped))
+	Expect(server.requests).To(Equal([]string{
+		"HEAD /generic-local/1.0.0/app-linux-amd64.tar.gz", "GET /generic-local/1.0.0/app-linux-amd64.tar.gz",
+		"HEAD /generic-local/1.0.0/SHA256SUMS", "GET /generic-local/1.0.0/SHA256SUMS",
+	}))
+
+	Expect(ioutil.WriteFile(artifacts[1].File, []byte("changed"), 0644)).To(BeNil())
+	results, err = Publish(publisher, artifacts, opts)
+	Expect(err).To(MatchError(ContainSubstring("already published with different checksum")))
+	Expect(results[1].Status).To(Equal(StatusFailed))
+	opts.Overwrite = true
+	results, err = Publish(publisher, artifacts, opts)
+	Expect(err).To(BeNil())
+	Expect(results[1].Status).To(Equal(StatusUploaded))
+
+	server.failures = 3
+	_, err = Publish(publisher, artifacts, opts)
+	Expect(err).To(MatchError(ContainSubstring("502 Bad Gateway")))
+	Expect(IsTemporary(err)).To(BeTrue())
+
+	publisher.Password = "wrong"
+	server.requests = nil
+	_, err = Publish(publisher, artifacts, opts)
+	Expect(err).To(MatchError(ContainSubstring("401 Unauthorized")))
+	Expect(server.requests).To(HaveLen(1))
+}
+
+func TestLocalPublisher(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmpDir, err := ioutil.TempDir("", "publish")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(tmpDir)
+	artifacts := writeArtifacts(tmpDir, map[string]string{"app-linux-amd64.tar.gz": "archive", "SHA256SUMS": "sums"})
+
+	cfg := Config{Type: TypeLocal, Local: LocalConfig{Dir: path.Join(tmpDir, "share")}}
+	publisher, err := New(cfg, Credentials{})
+	Expect(err).To(BeNil())
+	results, err := Publish(publisher, artifacts, cfg.Options())
+	Expect(err).To(BeNil())
+	Expect(results[1].Status).To(Equal(StatusUploaded))
+	content, err := ioutil.ReadFile(path.Join(tmpDir, "share", "1.0.0", "SHA256SUMS"))
+	Expect(err).To(BeNil())
+	Expect(string(content)).To(Equal("sums"))
+	files, err := ioutil.ReadDir(path.Join(tmpDir, "share", "1.0.0"))
+	Expect(err).To(BeNil())
+	Expect(files).To(HaveLen(2))
+
+	results, err = Publish(publisher, artifacts, cfg.Options())
+	Expect(err).To(BeNil())
+	Expect(results[0].Status).To(Equal(StatusSkipped))
+
+	_, err = Publish(publisher, []Artifact{{File: artifacts[0].File, Name: "../outside"}}, cfg.Options())
+	Expect(err).To(MatchError(ContainSubstring("is outside of")))
+}
+
+func TestConfigValidate(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect((&Config{}).Validate()).To(BeNil())
+	Expect((&Config{Type: "ftp"}).Validate()).To(MatchError(ContainSubstring("unsupported publish type")))
+	Expect((&Config{Type: TypeHTTP}).Validate()).To(MatchError("publish.http.url is required"))
+	Expect((&Config{Type: TypeS3, S3: S3Config{Bucket: "releases"}}).Validate()).NotTo(BeNil())
+	_, err := New(Config{}, Credentials{})
+	Expect(err).To(MatchError("publishing is not configured"))
+
+	retries := func(n int64) *int64 { return &n }
+	opts := (&Config{Retries: retries(5)}).Options()
+	Expect(opts.Retries).To(Equal(5))
+	Expect(opts.RetryDelay).To(Equal(DefaultRetryDelay))
+	Expect((&Config{}).Options().Retries).To(Equal(DefaultRetries))
+	Expect((&Config{Retries: retries(0)}).Options().Retries).To(BeZero())
+	Expect((&Config{Type: TypeLocal, Local: LocalConfig{Dir: "releases"}, Retries: retries(-1)}).Validate()).
+		To(MatchError("publish.retries must not be negative: -1"))
+	Expect(strings.HasPrefix(NewS3Publisher("", "eu-west-1", "releases").Endpoint, "https://s3.eu-west-1.")).To(BeTrue())
+}
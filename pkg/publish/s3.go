@@ -0,0 +1,265 @@
+package publish
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	headerAmzDate          = "X-Amz-Date"
+	headerAmzContentSHA256 = "X-Amz-Content-Sha256"
+	headerAmzSecurityToken = "X-Amz-Security-Token"
+	headerAmzMetaSHA256    = "X-Amz-Meta-Sha256"
+
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4Service    = "s3"
+	sigV4Terminator = "aws4_request"
+	amzDateFormat   = "20060102T150405Z"
+
+	// emptyPayloadSHA256 is SHA-256 of the empty body of HEAD and GET requests
+	emptyPayloadSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Publisher uploads artifacts to S3-compatible object storage (AWS S3, MinIO, Ceph etc.) signing requests
+// with AWS Signature Version 4, checksum is stored in x-amz-meta-sha256 metadata of the object
+type S3Publisher struct {
+	Endpoint     string
+	Region       string
+	Bucket       string
+	Prefix       string
+	PathStyle    bool
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Timeout      time.Duration
+	Client       *http.Client
+}
+
+// NewS3Publisher returns publisher uploading artifacts to the bucket (endpoint of AWS S3 in region if empty)
+func NewS3Publisher(endpoint string, region string, bucket string) *S3Publisher {
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	return &S3Publisher{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Region:   region,
+		Bucket:   bucket,
+		Timeout:  5 * time.Minute,
+		Client:   http.DefaultClient,
+	}
+}
+
+func (p *S3Publisher) String() string {
+	return fmt.Sprintf("s3://%s/%s", p.Bucket, strings.Trim(p.Prefix, "/"))
+}
+
+// Stat sends HEAD request taking checksum from object metadata,
+// the object is downloaded to calculate checksum if it has no checksum metadata
+func (p *S3Publisher) Stat(name string) (Object, error) {
+	resp, err := p.do(http.MethodHead, name, nil, 0, emptyPayloadSHA256, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return Object{}, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return Object{}, statusError(http.MethodHead, p.location(name), resp)
+	}
+	if checksum := resp.Header.Get(headerAmzMetaSHA256); checksum != "" {
+		return Object{Exists: true, SHA256: strings.ToLower(checksum)}, nil
+	}
+	resp, err = p.do(http.MethodGet, name, nil, 0, emptyPayloadSHA256, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Object{}, statusError(http.MethodGet, p.location(name), resp)
+	}
+	checksum, err := readerChecksum(resp.Body)
+	if err != nil {
+		return Object{}, Temporary(errors.Wrapf(err, "failed to download %s", name))
+	}
+	return Object{Exists: true, SHA256: checksum}, nil
+}
+
+// Put uploads file with PUT Object request (payload is signed with checksum of the file)
+func (p *S3Publisher) Put(name string, file string, checksum string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set(headerAmzMetaSHA256, checksum)
+	header.Set("Content-Type", "application/octet-stream")
+	resp, err := p.do(http.MethodPut, name, f, info.Size(), checksum, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return statusError(http.MethodPut, p.location(name), resp)
+	}
+	return nil
+}
+
+// Sign adds AWS Signature Version 4 to the request made at the time
+func (p *S3Publisher) Sign(req *http.Request, payloadSHA256 string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format(amzDateFormat)
+	scope := strings.Join([]string{t.Format("20060102"), p.Region, sigV4Service, sigV4Terminator}, "/")
+	req.Header.Set(headerAmzDate, amzDate)
+	req.Header.Set(headerAmzContentSHA256, payloadSHA256)
+	if p.SessionToken != "" {
+		req.Header.Set(headerAmzSecurityToken, p.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "range" || name == "content-type" || name == "content-md5" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadSHA256,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+	key := hmacSHA256([]byte("AWS4"+p.SecretKey), t.Format("20060102"))
+	for _, part := range []string{p.Region, sigV4Service, sigV4Terminator} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, p.AccessKey, scope, signedHeaders, signature))
+}
+
+func (p *S3Publisher) do(method string, name string, body io.Reader, size int64, payloadSHA256 string, header http.Header) (*http.Response, error) {
+	objectURL, err := p.objectURL(name)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, objectURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.URL = objectURL
+	req.ContentLength = size
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	p.Sign(req, payloadSHA256, time.Now())
+	return doRequest(p.Client, req, p.Timeout)
+}
+
+// objectURL returns URL of the object in virtual-hosted style (bucket.endpoint/key) or path style (endpoint/bucket/key)
+func (p *S3Publisher) objectURL(name string) (*url.URL, error) {
+	res, err := url.Parse(p.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "bad S3 endpoint: %q", p.Endpoint)
+	}
+	key := p.key(name)
+	if p.PathStyle {
+		res.Path = "/" + p.Bucket + "/" + key
+		res.RawPath = "/" + awsURIEncode(p.Bucket) + "/" + awsURIEncode(key)
+	} else {
+		res.Host = p.Bucket + "." + res.Host
+		res.Path = "/" + key
+		res.RawPath = "/" + awsURIEncode(key)
+	}
+	return res, nil
+}
+
+// key returns key of the object of the artifact
+func (p *S3Publisher) key(name string) string {
+	prefix := strings.Trim(p.Prefix, "/")
+	if prefix == "" {
+		return strings.TrimPrefix(name, "/")
+	}
+	return prefix + "/" + strings.TrimPrefix(name, "/")
+}
+
+func (p *S3Publisher) location(name string) string {
+	return fmt.Sprintf("s3://%s/%s", p.Bucket, p.key(name))
+}
+
+// awsURIEncode encodes everything except unreserved characters and slashes as SigV4 requires
+func awsURIEncode(value string) string {
+	var res strings.Builder
+	for _, b := range []byte(value) {
+		if b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' ||
+			b == '-' || b == '.' || b == '_' || b == '~' || b == '/' {
+			res.WriteByte(b)
+		} else {
+			res.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return res.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, strings.Replace(awsURIEncode(key), "/", "%2F", -1)+"="+strings.Replace(awsURIEncode(value), "/", "%2F", -1))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
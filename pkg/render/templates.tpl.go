@@ -1,5 +1,8 @@
 // Package render Code generated by go-bindata. (@generated) DO NOT EDIT.
 // sources:
+// buildinfo/version.tpl
+// changelog/default.tpl
+// config/diff.tpl
 // test/something/info.tpl
 package render
 
@@ -77,6 +80,66 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
+var _buildinfoVersionTpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x0e\x00\xf1\xff\x7b\x7b\x20\x2e\x53\x74\x72\x69\x6e\x67\x20\x7d\x7d\x0a\x03\x00\xc7\x02\x8e\x75\x0e\x00\x00\x00")
+
+func buildinfoVersionTplBytes() ([]byte, error) {
+	return bindataRead(
+		_buildinfoVersionTpl,
+		"buildinfo/version.tpl",
+	)
+}
+
+func buildinfoVersionTpl() (*asset, error) {
+	bytes, err := buildinfoVersionTplBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "buildinfo/version.tpl", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _changelogDefaultTpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x91\x3f\x6b\xc3\x30\x10\xc5\x77\x7f\x8a\x03\x2d\xb5\xa1\xfe\x00\xdd\x92\xc6\x24\x21\x25\x43\xd4\x76\x29\x1d\x84\x7b\x4d\x44\x83\x1c\x74\xea\x50\x8e\xfb\xee\xe5\x64\x23\xf7\xcf\x98\x4d\x77\xef\xf1\x7b\x8f\x93\x31\xc0\x0c\xed\x33\x46\xf2\x43\x00\x11\xb8\xd1\x79\xe5\x12\x82\x48\x5d\x31\xdf\x82\x7f\x87\x76\x19\xd1\x7d\xf8\x70\x04\x91\xaa\x32\xc6\xc0\xf2\xd0\x2d\x76\xdb\xfd\x1a\xee\x37\x8b\xfd\xba\xb3\x15\x33\x44\x17\x8e\xf8\xdb\xdb\x28\x5e\x01\xb6\x1f\x2e\x8a\x6c\x1a\xe6\x79\xba\x6b\xb2\x01\xc3\x1b\x88\xa8\xb0\x42\xea\xa3\xbf\xa4\xb1\x4c\x8e\x1f\xc5\xbf\xcf\x29\xcb\x62\xaf\x5e\x2a\xbd\x14\xf2\xe8\xd3\x59\xe9\x3f\x3a\xe5\x40\x75\xcd\xab\x2e\xa4\xe8\x91\xae\x6f\x39\x23\xb7\x44\x9f\x99\x98\xaf\xa8\xc0\xa7\xc3\x03\x88\xbc\x28\x6d\x87\x5f\x20\xf2\xaa\xca\xb4\xae\x15\x7a\x26\x9c\xa8\xa3\xa1\x04\xd5\xe5\x95\x69\xad\x3d\x0d\x31\x6d\x1c\x9d\xca\xc7\x94\x42\xff\x4f\xf4\x3d\x00\xc2\xf8\x7a\xa2\xd8\x01\x00\x00")
+
+func changelogDefaultTplBytes() ([]byte, error) {
+	return bindataRead(
+		_changelogDefaultTpl,
+		"changelog/default.tpl",
+	)
+}
+
+func changelogDefaultTpl() (*asset, error) {
+	bytes, err := changelogDefaultTplBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "changelog/default.tpl", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _configDiffTpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd2\xd5\xd5\x55\xa8\xae\x56\xd0\x73\x2b\xca\xcf\x55\xa8\xad\xe5\xd2\xd6\xd6\x06\xf3\x43\xf2\x41\xbc\xea\x6a\x85\xa2\xc4\xbc\xf4\x54\x05\x3d\xe7\x0c\x10\x5d\xac\xa0\x0b\x11\xcd\x4c\x53\x48\x2d\x54\xd0\xf3\x2f\x50\x50\xd2\x56\x52\xa8\xad\x85\x68\x0a\x48\x2c\xc9\x50\xa8\xad\xb5\x02\x73\xfc\x52\xcb\xa1\x46\xa4\xe6\x14\xa7\x22\xeb\xd0\x05\xe9\xd0\xc5\xd4\xe1\x9f\x93\x82\xac\xa3\xb6\xb6\x0e\x97\x1a\x05\x5d\x3b\x74\x3b\xf2\x40\xe2\xd5\xd5\x0a\xa9\x79\x29\x0a\xba\xb5\xb5\x5c\x80\x01\x00\x55\x34\x67\x58\xd9\x00\x00\x00")
+
+func configDiffTplBytes() ([]byte, error) {
+	return bindataRead(
+		_configDiffTpl,
+		"config/diff.tpl",
+	)
+}
+
+func configDiffTpl() (*asset, error) {
+	bytes, err := configDiffTplBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "config/diff.tpl", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _testSomethingInfoTpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x0a\xce\xcf\x4d\x0d\x49\xcd\x2d\xc8\x49\x2c\x49\xb5\x52\xa8\xae\xd6\xf3\x4b\xcc\x4d\xad\xad\xe5\x02\x04\x00\x00\xff\xff\x1a\x7d\x64\xc2\x18\x00\x00\x00")
 
 func testSomethingInfoTplBytes() ([]byte, error) {
@@ -149,6 +212,9 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
+	"buildinfo/version.tpl":   buildinfoVersionTpl,
+	"changelog/default.tpl":   changelogDefaultTpl,
+	"config/diff.tpl":         configDiffTpl,
 	"test/something/info.tpl": testSomethingInfoTpl,
 }
 
@@ -193,6 +259,15 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
+	"buildinfo": &bintree{nil, map[string]*bintree{
+		"version.tpl": &bintree{buildinfoVersionTpl, map[string]*bintree{}},
+	}},
+	"changelog": &bintree{nil, map[string]*bintree{
+		"default.tpl": &bintree{changelogDefaultTpl, map[string]*bintree{}},
+	}},
+	"config": &bintree{nil, map[string]*bintree{
+		"diff.tpl": &bintree{configDiffTpl, map[string]*bintree{}},
+	}},
 	"test": &bintree{nil, map[string]*bintree{
 		"something": &bintree{nil, map[string]*bintree{
 			"info.tpl": &bintree{testSomethingInfoTpl, map[string]*bintree{}},
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -20,3 +22,68 @@ func FormatDurationSec(lengthNs int64) string {
 	}
 	return stringDuration
 }
+
+const (
+	Day = 24 * time.Hour
+)
+
+// ParseDuration parses duration string in Go format additionally supporting days (e.g. "2d12h")
+// and bare numbers which are treated as seconds
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("invalid duration: empty value")
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	sign := time.Duration(1)
+	rest := value
+	if strings.HasPrefix(rest, "-") {
+		sign, rest = -1, rest[1:]
+	}
+	var days time.Duration
+	if idx := strings.Index(rest, "d"); idx > 0 {
+		numDays, err := strconv.ParseFloat(rest[:idx], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %q", value)
+		}
+		days = time.Duration(numDays * float64(Day))
+		rest = rest[idx+1:]
+	}
+	if rest == "" {
+		return sign * days, nil
+	}
+	duration, err := time.ParseDuration(rest)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid duration: %q", value)
+	}
+	return sign * (days + duration), nil
+}
+
+// HumanDuration formats duration in the shortest human-friendly form (e.g. "2d", "1h30m", "90ms")
+func HumanDuration(duration time.Duration) string {
+	if duration == 0 {
+		return "0s"
+	}
+	sign := ""
+	if duration < 0 {
+		sign, duration = "-", -duration
+	}
+	days := duration / Day
+	rest := duration % Day
+	res := ""
+	if days > 0 {
+		res = fmt.Sprintf("%dd", days)
+	}
+	if rest > 0 {
+		restString := rest.String()
+		for _, zeroSuffix := range []string{"m0s", "h0m"} {
+			if strings.HasSuffix(restString, zeroSuffix) {
+				restString = restString[:len(restString)-2]
+			}
+		}
+		res += restString
+	}
+	return sign + res
+}
@@ -2,6 +2,7 @@ package util_test
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	. "github.com/smecsia/go-utils/pkg/util"
@@ -18,3 +19,40 @@ func TestFormatDuration(t *testing.T) {
 	Expect(FormatDurationSec(60000 * 1000000)).To(Equal("1m"))
 	Expect(FormatDurationSec(3600000 * 1000000)).To(Equal("1h"))
 }
+
+func TestParseDuration(t *testing.T) {
+	RegisterTestingT(t)
+
+	for value, expected := range map[string]time.Duration{
+		"90s":      90 * time.Second,
+		"1h30m":    90 * time.Minute,
+		"2d":       48 * time.Hour,
+		"1d12h30m": 36*time.Hour + 30*time.Minute,
+		"1.5d":     36 * time.Hour,
+		"-2d":      -48 * time.Hour,
+		"90":       90 * time.Second,
+		"250ms":    250 * time.Millisecond,
+	} {
+		duration, err := ParseDuration(value)
+		Expect(err).To(BeNil(), value)
+		Expect(duration).To(Equal(expected), value)
+	}
+
+	for _, value := range []string{"", "d", "2x", "1d-2h", "abc"} {
+		_, err := ParseDuration(value)
+		Expect(err).NotTo(BeNil(), value)
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(HumanDuration(0)).To(Equal("0s"))
+	Expect(HumanDuration(90 * time.Second)).To(Equal("1m30s"))
+	Expect(HumanDuration(90 * time.Minute)).To(Equal("1h30m"))
+	Expect(HumanDuration(2 * time.Hour)).To(Equal("2h"))
+	Expect(HumanDuration(48 * time.Hour)).To(Equal("2d"))
+	Expect(HumanDuration(49*time.Hour + time.Second)).To(Equal("2d1h0m1s"))
+	Expect(HumanDuration(250 * time.Millisecond)).To(Equal("250ms"))
+	Expect(HumanDuration(-48 * time.Hour)).To(Equal("-2d"))
+}
@@ -1,9 +1,11 @@
 package util
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 )
 
 type Logger interface {
@@ -95,3 +97,79 @@ func (l *PrefixLogger) SubLogger(name string) Logger {
 func (l *PrefixLogger) Debugf(format string, msg ...interface{}) {
 	l.Logf(format, msg...)
 }
+
+// WriterLogger writes prefixed lines to the writer
+type WriterLogger struct {
+	prefix string
+	writer io.Writer
+}
+
+// PrefixWriter prefixes every line written to the underlying writer (incomplete line is kept until Flush)
+type PrefixWriter struct {
+	mutex  sync.Mutex
+	prefix string
+	writer io.Writer
+	buf    []byte
+}
+
+func NewWriterLogger(writer io.Writer, prefix string) *WriterLogger {
+	return &WriterLogger{
+		prefix: prefix,
+		writer: writer,
+	}
+}
+
+func NewPrefixWriter(writer io.Writer, prefix string) *PrefixWriter {
+	return &PrefixWriter{
+		prefix: prefix,
+		writer: writer,
+	}
+}
+
+func (l *WriterLogger) Logf(format string, msg ...interface{}) {
+	message := strings.Trim(fmt.Sprintf(format, msg...), "\n")
+	if l.prefix != "" {
+		message = l.prefix + " " + message
+	}
+	_, _ = l.writer.Write([]byte(message + "\n"))
+}
+
+func (l *WriterLogger) SubLogger(name string) Logger {
+	return &WriterLogger{
+		prefix: strings.TrimSpace(l.prefix + " [" + name + "]"),
+		writer: l.writer,
+	}
+}
+
+func (l *WriterLogger) Debugf(format string, msg ...interface{}) {
+	l.Logf(format, msg...)
+}
+
+// Write writes complete lines prefixing each of them
+func (w *PrefixWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			return len(p), nil
+		}
+		if _, err := w.writer.Write(append([]byte(w.prefix), w.buf[:idx+1]...)); err != nil {
+			return len(p), err
+		}
+		w.buf = w.buf[idx+1:]
+	}
+}
+
+// Flush writes remaining incomplete line
+func (w *PrefixWriter) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.writer.Write(append(append([]byte(w.prefix), w.buf...), '\n'))
+	w.buf = nil
+	return err
+}
@@ -0,0 +1,27 @@
+package util_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/util"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	RegisterTestingT(t)
+
+	var buf bytes.Buffer
+	writer := NewPrefixWriter(&buf, "[app] ")
+	_, _ = writer.Write([]byte("first\nsec"))
+	_, _ = writer.Write([]byte("ond\nthird"))
+	Expect(buf.String()).To(Equal("[app] first\n[app] second\n"))
+	Expect(writer.Flush()).To(BeNil())
+	Expect(buf.String()).To(Equal("[app] first\n[app] second\n[app] third\n"))
+
+	buf.Reset()
+	logger := NewWriterLogger(&buf, "[app]")
+	logger.Logf("building %s", "linux")
+	logger.SubLogger("tar").Logf("done\n")
+	Expect(buf.String()).To(Equal("[app] building linux\n[app] [tar] done\n"))
+}
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes which can be defined in human-friendly form (e.g. "512Mi", "1.5GB")
+type ByteSize int64
+
+const (
+	Byte ByteSize = 1
+
+	KB = 1000 * Byte
+	MB = 1000 * KB
+	GB = 1000 * MB
+	TB = 1000 * GB
+	PB = 1000 * TB
+
+	KiB = 1024 * Byte
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+	TiB = 1024 * GiB
+	PiB = 1024 * TiB
+)
+
+type byteUnit struct {
+	name string
+	size ByteSize
+}
+
+var (
+	binaryUnits  = []byteUnit{{"Pi", PiB}, {"Ti", TiB}, {"Gi", GiB}, {"Mi", MiB}, {"Ki", KiB}}
+	decimalUnits = []byteUnit{{"PB", PB}, {"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB}}
+	unitSizes    = map[string]ByteSize{
+		"": Byte, "b": Byte,
+		"k": KB, "kb": KB, "m": MB, "mb": MB, "g": GB, "gb": GB, "t": TB, "tb": TB, "p": PB, "pb": PB,
+		"ki": KiB, "kib": KiB, "mi": MiB, "mib": MiB, "gi": GiB, "gib": GiB, "ti": TiB, "tib": TiB, "pi": PiB, "pib": PiB,
+	}
+)
+
+// ParseByteSize parses size with optional decimal (KB, MB...) or binary (Ki, Mi...) unit
+func ParseByteSize(value string) (ByteSize, error) {
+	value = strings.TrimSpace(value)
+	idx := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+	number, unit := value, ""
+	if idx >= 0 {
+		number, unit = value[:idx], strings.TrimSpace(value[idx:])
+	}
+	unitSize, ok := unitSizes[strings.ToLower(unit)]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid size: %q", value)
+	}
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size: %q", value)
+	}
+	return ByteSize(math.Round(amount * float64(unitSize))), nil
+}
+
+// String formats size using the largest unit the size can be represented exactly with
+func (s ByteSize) String() string {
+	if s == 0 {
+		return "0"
+	}
+	for _, unit := range binaryUnits {
+		if s%unit.size == 0 {
+			return fmt.Sprintf("%d%s", s/unit.size, unit.name)
+		}
+	}
+	for _, unit := range decimalUnits {
+		// integer arithmetic keeps formatting exact for sizes beyond float64 precision
+		if abs(s) >= unit.size && s%(unit.size/100) == 0 {
+			res := fmt.Sprintf("%d.%02d", s/unit.size, abs(s%unit.size)/(unit.size/100))
+			return strings.TrimSuffix(strings.TrimSuffix(res, "0"), ".0") + unit.name
+		}
+	}
+	return fmt.Sprintf("%dB", s)
+}
+
+// MarshalYAML writes size in human-friendly form
+func (s ByteSize) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// UnmarshalYAML reads size in human-friendly form
+func (s *ByteSize) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	size, err := ParseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// MarshalJSON writes size in human-friendly form
+func (s ByteSize) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON reads size either as a number of bytes or in human-friendly form
+func (s *ByteSize) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	text := fmt.Sprint(value)
+	if number, ok := value.(float64); ok {
+		text = strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	size, err := ParseByteSize(text)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+func abs(s ByteSize) ByteSize {
+	if s < 0 {
+		return -s
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package util_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/smecsia/go-utils/pkg/util"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseByteSize(t *testing.T) {
+	RegisterTestingT(t)
+
+	for value, expected := range map[string]ByteSize{
+		"512Mi":  512 * MiB,
+		"512MiB": 512 * MiB,
+		"1.5GB":  1500 * MB,
+		"1.5G":   1500 * MB,
+		"10k":    10 * KB,
+		"100":    100,
+		"100B":   100,
+		"2 Gi":   2 * GiB,
+	} {
+		size, err := ParseByteSize(value)
+		Expect(err).To(BeNil(), value)
+		Expect(size).To(Equal(expected), value)
+	}
+
+	for _, value := range []string{"", "Mi", "1.5XB", "abc"} {
+		_, err := ParseByteSize(value)
+		Expect(err).NotTo(BeNil(), value)
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(ByteSize(0).String()).To(Equal("0"))
+	Expect((512 * MiB).String()).To(Equal("512Mi"))
+	Expect((1500 * MB).String()).To(Equal("1.5GB"))
+	Expect((3 * GiB).String()).To(Equal("3Gi"))
+	Expect(ByteSize(1001).String()).To(Equal("1001B"))
+	Expect((100000000000000*KB + 10).String()).To(Equal("100000000000000.01KB"))
+	Expect(ByteSize(math.MaxInt64).String()).To(Equal("9223372036854775807B"))
+}
+
+type sizes struct {
+	Memory ByteSize `yaml:"memory" json:"memory"`
+}
+
+func TestMarshalByteSize(t *testing.T) {
+	RegisterTestingT(t)
+
+	var fromYaml sizes
+	Expect(yaml.Unmarshal([]byte("memory: 1.5GB"), &fromYaml)).To(BeNil())
+	Expect(fromYaml.Memory).To(Equal(1500 * MB))
+	out, err := yaml.Marshal(fromYaml)
+	Expect(err).To(BeNil())
+	Expect(string(out)).To(Equal("memory: 1.5GB\n"))
+
+	var fromJSON sizes
+	Expect(json.Unmarshal([]byte(`{"memory": 1024}`), &fromJSON)).To(BeNil())
+	Expect(fromJSON.Memory).To(Equal(KiB))
+	out, err = json.Marshal(fromJSON)
+	Expect(err).To(BeNil())
+	Expect(string(out)).To(Equal(`{"memory":"1Ki"}`))
+}
@@ -23,6 +23,17 @@ func (y *YamlEdit) ModifyProperty(filePath string, yamlPath string, value string
 	})
 }
 
+// ModifyDocument sets value at the path of yaml document returning updated document
+// (only the first document of the stream is used)
+func (y *YamlEdit) ModifyDocument(data []byte, yamlPath string, value interface{}) ([]byte, error) {
+	yaml.DefaultMapType = reflect.TypeOf(yaml.MapSlice{})
+	var dataBucket dataBucketType
+	if err := yaml.Unmarshal(data, &dataBucket); err != nil {
+		return nil, errors.Wrapf(err, "Error reading document")
+	}
+	return yaml.Marshal(y.updatedChildValue(dataBucket, y.parsePath(yamlPath), value))
+}
+
 func (y *YamlEdit) updatedChildValue(child dataBucketType, remainingPaths []string, value dataBucketType) dataBucketType {
 	if len(remainingPaths) == 0 {
 		return value
@@ -212,6 +223,11 @@ func (y *YamlEdit) readData(filename string, indexToRead int, parsedData interfa
 	})
 }
 
+// ParsePath splits path into keys and list indexes (e.g. a.b[0] -> a, b, 0)
+func (y *YamlEdit) ParsePath(path string) []string {
+	return y.parsePath(path)
+}
+
 func (y *YamlEdit) parsePath(path string) []string {
 	return y.parsePathAccum([]string{}, path)
 }
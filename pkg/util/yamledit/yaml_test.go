@@ -41,3 +41,18 @@ func TestYamlEdit(t *testing.T) {
 	Expect(string(bytes)).NotTo(ContainSubstring("armory: ${ARMORY_IMAGE}"))
 	Expect(string(bytes)).To(ContainSubstring("name: ${ARMORY_IMAGE}"))
 }
+
+func TestYamlEditModifyDocument(t *testing.T) {
+	RegisterTestingT(t)
+
+	yedit := YamlEdit{}
+	data, err := yedit.ModifyDocument([]byte("items:\n- name: a\n"), "items[+].name", "b")
+	Expect(err).To(BeNil())
+	data, err = yedit.ModifyDocument(data, "items[0].count", 2)
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal("items:\n- name: a\n  count: 2\n- name: b\n"))
+
+	data, err = yedit.ModifyDocument(nil, `"a.b".c`, "value")
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal("a.b:\n  c: value\n"))
+}
@@ -0,0 +1 @@
+{{ .String }}
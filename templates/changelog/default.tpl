@@ -0,0 +1,15 @@
+## {{ .Version }} ({{ .Date }})
+{{- if .Breaking }}
+
+### BREAKING CHANGES
+{{ range .Breaking }}
+* {{ if .Scope }}**{{ .Scope }}:** {{ end }}{{ .Description }}
+{{- end }}
+{{- end }}
+{{- range .Sections }}
+
+### {{ .Title }}
+{{ range .Scopes }}{{ range .Entries }}
+* {{ if .Scope }}**{{ .Scope }}:** {{ end }}{{ .Description }}{{ range .Issues }} ({{ if .URL }}[{{ .Key }}]({{ .URL }}){{ else }}{{ .Key }}{{ end }}){{ end }} ({{ .ShortHash }})
+{{- end }}{{ end }}
+{{- end }}
@@ -0,0 +1,7 @@
+--- {{ .From }}
++++ {{ .To }}
+{{ range .Changes -}}
+{{ if eq .Op "+" }}+ {{ .Path }}: {{ .New }}
+{{ else if eq .Op "-" }}- {{ .Path }}: {{ .Old }}
+{{ else }}~ {{ .Path }}: {{ .Old }} -> {{ .New }}
+{{ end }}{{ end -}}